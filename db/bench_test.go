@@ -0,0 +1,60 @@
+package db_test
+
+import (
+	"context"
+	"math/rand"
+	"pardusdb/db"
+	"testing"
+)
+
+// fakeEmbedder hands back deterministic random vectors instead of
+// calling a real model, so these benchmarks measure Query/InsertRows
+// overhead rather than network latency.
+type fakeEmbedder struct {
+	dim int
+	r   *rand.Rand
+}
+
+func (f *fakeEmbedder) Name() string { return "fake" }
+func (f *fakeEmbedder) Dim() int     { return f.dim }
+func (f *fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		v := make([]float32, f.dim)
+		for d := range v {
+			v[d] = f.r.Float32()
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// BenchmarkQueryFlat exercises Query against a table holding 100k rows
+// under the IVF/flat index, to compare throughput against the fixed
+// cosine similarity and its SIMD kernels in pardusdb/vec.
+func BenchmarkQueryFlat(b *testing.B) {
+	const dim = 64
+	const n = 100_000
+
+	pardus := db.CreateDB("bench")
+	embedder := &fakeEmbedder{dim: dim, r: rand.New(rand.NewSource(1))}
+	table, err := db.CreateTable("bench", 64, embedder, db.TableOptions{Nprobe: 4}, &pardus)
+	if err != nil {
+		b.Fatalf("create table: %v", err)
+	}
+
+	vals := make([]db.Val, n)
+	for i := range vals {
+		vals[i] = db.Val{Text: "row"}
+	}
+	if _, err := db.InsertRows("bench", vals, &pardus); err != nil {
+		b.Fatalf("insert rows: %v", err)
+	}
+
+	b.ResetTimer()
+	for range b.N {
+		if _, err := db.Query("row", table); err != nil {
+			b.Fatalf("query: %v", err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package db_test
+
+import (
+	"context"
+	"math/rand"
+	"pardusdb/db"
+	"sync"
+	"testing"
+)
+
+// raceEmbedder hands back deterministic random vectors like
+// fakeEmbedder in bench_test.go, but serializes access to its
+// *rand.Rand since this test (unlike the benchmark) calls Embed from
+// many goroutines at once.
+type raceEmbedder struct {
+	dim int
+
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (f *raceEmbedder) Name() string { return "race" }
+func (f *raceEmbedder) Dim() int     { return f.dim }
+func (f *raceEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		v := make([]float32, f.dim)
+		for d := range v {
+			v[d] = f.r.Float32()
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// TestConcurrentInsertAndQuery hammers a single table with concurrent
+// inserts and queries to prove Table.mu/PardusDB.mu actually serialize
+// the racy bits (Layer.Data, Layer.Centriod, table.Count) that the old
+// lock-free code left exposed. Run with -race to check.
+func TestConcurrentInsertAndQuery(t *testing.T) {
+	const dim = 16
+	const goroutines = 32
+	const rowsPerWriter = 25
+
+	pardus := db.CreateDB("race")
+	embedder := &raceEmbedder{dim: dim, r: rand.New(rand.NewSource(1))}
+	table, err := db.CreateTable("rows", 4, embedder, db.TableOptions{Nprobe: 2}, &pardus)
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range rowsPerWriter {
+				if err := db.InsertRow("rows", db.Val{Text: "row"}, &pardus); err != nil {
+					t.Errorf("insert row: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range rowsPerWriter {
+				if _, err := db.Query("row", table); err != nil {
+					t.Errorf("query: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := table.Count, uint32(goroutines*rowsPerWriter); got != want {
+		t.Fatalf("table.Count = %d, want %d", got, want)
+	}
+}
+
+// TestBatchIsAtomic inserts a batch from many goroutines at once and
+// checks the table ends up with exactly the rows every batch submitted,
+// which would drift if Batch's single write-lock hold were lost.
+func TestBatchIsAtomic(t *testing.T) {
+	const dim = 8
+	const goroutines = 16
+	const rowsPerBatch = 10
+
+	pardus := db.CreateDB("race-batch")
+	embedder := &raceEmbedder{dim: dim, r: rand.New(rand.NewSource(2))}
+	if _, err := db.CreateTable("rows", 4, embedder, db.TableOptions{}, &pardus); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	vals := make([]db.Val, rowsPerBatch)
+	for i := range vals {
+		vals[i] = db.Val{Text: "row"}
+	}
+
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := db.Batch("rows", vals, &pardus); err != nil {
+				t.Errorf("batch: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	table, found := pardus.Table("rows")
+	if !found {
+		t.Fatalf("table not found after concurrent batches")
+	}
+	if got, want := table.Count, uint32(goroutines*rowsPerBatch); got != want {
+		t.Fatalf("table.Count = %d, want %d", got, want)
+	}
+}
@@ -1,22 +1,43 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
-	"math"
 	"pardusdb/embed"
+	"pardusdb/index/hnsw"
+	"pardusdb/vec"
 	"sort"
+	"sync"
 	"time"
 )
 
-// the key db file is placed here
-const MODEL = "nomic-embed-text:latest"
 const THRESHOLD = 0.9
 
+// IndexKind selects which nearest-neighbor strategy a table's Query
+// uses. The zero value, "", behaves as IndexFlat, the original IVF/layer
+// scan.
+type IndexKind string
+
+const (
+	IndexFlat IndexKind = "flat"
+	IndexHNSW IndexKind = "hnsw"
+)
+
+// defaultHNSWTopK is how many candidates Query asks the HNSW graph for
+// when a table doesn't override it via TableOptions.TopK.
+const defaultHNSWTopK = 10
+
 type PardusDB struct {
 	Name   string
 	Tables map[string]*Table
+
+	// mu guards the Tables map itself (creating/looking up tables), not
+	// what's inside any one *Table - that's Table.mu's job. It's a
+	// pointer so PardusDB stays safe to return and assign by value, the
+	// way CreateDB's callers already use it.
+	mu *sync.RWMutex
 }
 
 type Table struct {
@@ -25,12 +46,87 @@ type Table struct {
 	Capacity uint32 //max no. of layer
 	Count    uint32 //current layer
 
-	pointer uint32 // round rubin
-	Layers  []Layer
+	// mu guards every field below against concurrent InsertRow/InsertRows
+	// (write-locked) and Query (read-locked) calls on the same table.
+	mu sync.RWMutex
+
+	Layers []Layer
+
+	// Nprobe is how many of the nearest centroids Query inspects before
+	// ranking candidates. 1 means "only the single closest layer",
+	// matching the old flat-scan behavior.
+	Nprobe uint32
+
+	// Embedder is the backend used to turn inserted/queried text into
+	// vectors for this table. All rows in a table must come from the
+	// same embedder so their vectors stay comparable.
+	Embedder embed.Embedder
+
+	// EmbedderSpec is how Embedder was built, if it came from
+	// TableOptions.EmbedderSpec.Build(). A restored table (see
+	// Handle.restoreTable/applyRecord) rebuilds Embedder from it
+	// automatically; it's the zero value for tables whose Embedder came
+	// from somewhere else (e.g. a test stub), which come back needing
+	// BindEmbedder after a restart same as before this field existed.
+	EmbedderSpec embed.Spec
+
+	// trainingSize is how many inserted vectors are buffered before the
+	// initial k-means pass seeds the layer centroids.
+	trainingSize uint32
+	// varianceThreshold triggers a full re-cluster once a layer's
+	// intra-cluster variance grows past it. <= 0 disables the check.
+	varianceThreshold float32
+
+	trained        bool
+	trainingBuffer []Object
+
+	// index selects which of the fields above/below is actually live.
+	index IndexKind
+	topK  uint32
+
+	graph     *hnsw.Graph
+	graphObjs []Object // graphObjs[id] is the Object for hnsw node id
+}
 
-	Model string
+// TableOptions tunes the index a table is built with. The zero value
+// is a reasonable default for a small IVF-backed table.
+type TableOptions struct {
+	// Index picks the nearest-neighbor strategy. "" means IndexFlat.
+	Index IndexKind
+	// HNSW configures the graph when Index == IndexHNSW.
+	HNSW hnsw.Options
+
+	// TopK is how many candidates Query pulls from the index before
+	// ranking them. 0 picks defaultHNSWTopK (only used by IndexHNSW;
+	// IndexFlat ranks every row in the probed layers instead).
+	TopK uint32
+
+	// TrainingSize is how many inserted vectors to collect before
+	// running k-means to seed the layer centroids. 0, or any value below
+	// Capacity, picks defaultTrainingMultiplier * Capacity. Only used by
+	// IndexFlat.
+	TrainingSize uint32
+	// Nprobe is how many nearest centroids Query probes. 0 means 1.
+	// Only used by IndexFlat.
+	Nprobe uint32
+	// VarianceThreshold triggers periodic re-clustering; 0 disables it.
+	// Only used by IndexFlat.
+	VarianceThreshold float32
+
+	// EmbedderSpec records which backend/model/config built Embedder, so
+	// a restored table can rebuild it automatically instead of coming
+	// back with a nil Embedder that panics on the first insert. Leave it
+	// at its zero value if Embedder didn't come from embed.Spec.Build
+	// (e.g. a test stub); such a table just needs BindEmbedder called
+	// after a restart, same as before this field existed.
+	EmbedderSpec embed.Spec
 }
 
+// defaultTrainingMultiplier sizes the training buffer relative to the
+// number of clusters we need to seed, so k-means has enough points per
+// cluster to produce a meaningful centroid.
+const defaultTrainingMultiplier = 40
+
 type Layer struct {
 	Data     []Object
 	Centriod []float32
@@ -53,83 +149,234 @@ func CreateDB(name string) PardusDB {
 	return PardusDB{
 		Name:   name,
 		Tables: map[string]*Table{},
+		mu:     &sync.RWMutex{},
 	}
 }
 
+// Table looks up a table by name, safe for concurrent use alongside
+// CreateTable running on the same PardusDB.
+func (db *PardusDB) Table(name string) (*Table, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	t, found := db.Tables[name]
+	return t, found
+}
+
+// TableNames returns the name of every table currently in db, safe for
+// concurrent use alongside CreateTable.
+func (db *PardusDB) TableNames() []string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	names := make([]string, 0, len(db.Tables))
+	for name := range db.Tables {
+		names = append(names, name)
+	}
+	return names
+}
+
 func CreateTable(
-	name string, cap uint32, db *PardusDB,
+	name string, cap uint32, embedder embed.Embedder, opts TableOptions, db *PardusDB,
 ) (*Table, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
 
 	_, found := (*db).Tables[name]
 	if found {
 		return nil, fmt.Errorf("%s already exists in the db", name)
 	}
 
-	layers := []Layer{}
-
-	for range cap {
-		layers = append(layers, Layer{
-			Data:     []Object{},
-			Centriod: []float32{},
-		})
+	if embedder == nil {
+		return nil, fmt.Errorf("%s: embedder is required", name)
 	}
 
 	t := &Table{
-		Name:     name,
-		Capacity: cap,
-		Count:    0,
-		pointer:  0,
-		Layers:   layers,
+		Name:              name,
+		Capacity:          cap,
+		Count:             0,
+		Layers:            []Layer{},
+		Nprobe:            opts.Nprobe,
+		Embedder:          embedder,
+		EmbedderSpec:      opts.EmbedderSpec,
+		trainingSize:      opts.TrainingSize,
+		varianceThreshold: opts.VarianceThreshold,
+		index:             opts.Index,
+		topK:              opts.TopK,
+	}
+	if t.Nprobe == 0 {
+		t.Nprobe = 1
+	}
+	if t.topK == 0 {
+		t.topK = defaultHNSWTopK
+	}
+	// k-means needs at least as many training points as clusters.
+	if t.trainingSize < cap {
+		t.trainingSize = cap * defaultTrainingMultiplier
 	}
+
+	if t.index == IndexHNSW {
+		t.graph = hnsw.New(opts.HNSW)
+	}
+
 	db.Tables[t.Name] = t
 
 	return t, nil
 }
 
+// checkDim makes sure vector matches the table's embedder dimension, so
+// mixing models on the same table fails loudly instead of silently
+// producing meaningless similarity scores.
+func checkDim(table *Table, vector []float32) error {
+	if dim := table.Embedder.Dim(); dim > 0 && len(vector) != dim {
+		return fmt.Errorf(
+			"table %s: embedder %s produced a %d-dim vector, table expects %d",
+			table.Name, table.Embedder.Name(), len(vector), dim,
+		)
+	}
+	return nil
+}
+
 type sim struct {
 	sim   float32
 	index uint32
 }
 
-// some mutex shd be done here but let's finish the prototype first
+// Query embeds prompt and returns the closest match currently indexed
+// on table. It read-locks table for its whole duration, so it can run
+// concurrently with other Querys but waits out any InsertRow/InsertRows
+// in progress (and vice versa).
 func Query(
 	prompt string, table *Table,
 ) (Val, error) {
+	table.mu.RLock()
+	defer table.mu.RUnlock()
+
+	val, _, err := table.query(prompt, table.Nprobe, table.topK)
+	return val, err
+}
+
+// QueryOptions overrides a table's own configured Nprobe/TopK for a
+// single QueryWithOptions call. A zero field keeps the table's value.
+type QueryOptions struct {
+	TopK   uint32
+	Nprobe uint32
+}
+
+// QueryResult is a Query match plus the cosine similarity it scored,
+// for callers (like pardusdb/net/httpapi) that apply their own
+// threshold instead of trusting the match unconditionally.
+type QueryResult struct {
+	Val
+	Similarity float32
+}
+
+// QueryWithOptions is Query with per-call Nprobe/TopK overrides and the
+// winning similarity score attached to the result.
+func QueryWithOptions(prompt string, table *Table, opts QueryOptions) (QueryResult, error) {
+	table.mu.RLock()
+	defer table.mu.RUnlock()
+
+	nprobe := opts.Nprobe
+	if nprobe == 0 {
+		nprobe = table.Nprobe
+	}
+	topK := opts.TopK
+	if topK == 0 {
+		topK = table.topK
+	}
+
+	val, score, err := table.query(prompt, nprobe, topK)
+	return QueryResult{Val: val, Similarity: score}, err
+}
+
+// Size returns the number of vectors currently indexed in table, safe
+// for concurrent use alongside InsertRow/InsertRows.
+func (t *Table) Size() uint32 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.Count
+}
+
+// query is Query/QueryWithOptions's shared core: embed prompt, probe
+// nprobe centroids (or ask the HNSW graph for topK candidates), and
+// rank the result. Callers must already hold at least table.mu's read
+// lock.
+func (t *Table) query(prompt string, nprobe, topK uint32) (Val, float32, error) {
 	// the function should be embeded the prompt and hen calculate
-	if table.Count == 0 {
+	if t.Count == 0 {
 		// query database
-		return Val{}, nil
+		return Val{}, 0, nil
 	}
 
-	vector, err := embed.OllamaEmbedding(prompt, MODEL)
-
+	vectors, err := t.Embedder.Embed(context.Background(), []string{prompt})
 	if err != nil {
 		slog.Error("embedding error", "error", err)
-		return Val{}, err
+		return Val{}, 0, err
 	}
+	vector := vectors[0]
 
-	simScore := []sim{}
+	if err := checkDim(t, vector); err != nil {
+		return Val{}, 0, err
+	}
+
+	if t.index == IndexHNSW {
+		ids := t.graph.Search(vector, int(topK))
+		candidates := make([]Object, 0, len(ids))
+		for _, id := range ids {
+			candidates = append(candidates, t.graphObjs[id])
+		}
+		return bestMatch(candidates, vector)
+	}
+
+	// Still collecting the initial training set: there's no index to
+	// probe yet, so fall back to a flat scan over the buffered rows.
+	if !t.trained {
+		return bestMatch(t.trainingBuffer, vector)
+	}
 
-	for i := range min(table.Capacity, table.Count) {
-		s, err := similarity(table.Layers[i].Centriod, vector)
+	centroidScore := []sim{}
+	for i := range t.Layers {
+		s, err := similarity(t.Layers[i].Centriod, vector)
 		if err != nil {
-			return Val{}, err
+			return Val{}, 0, err
 		}
-		simScore = append(simScore, sim{
-			sim:   s,
-			index: i,
-		})
+		centroidScore = append(centroidScore, sim{sim: s, index: uint32(i)})
 	}
 
-	sort.Slice(simScore, func(i, j int) bool {
-		return simScore[i].sim > simScore[j].sim
+	sort.Slice(centroidScore, func(i, j int) bool {
+		return centroidScore[i].sim > centroidScore[j].sim
 	})
 
-	layer := table.Layers[simScore[0].index]
+	n := int(nprobe)
+	if n <= 0 {
+		n = 1
+	}
+	if n > len(centroidScore) {
+		n = len(centroidScore)
+	}
 
-	simScore = []sim{}
-	for i, ele := range layer.Data {
-		s, _ := similarity(ele.Vector, vector)
+	candidates := []Object{}
+	for _, c := range centroidScore[:n] {
+		candidates = append(candidates, t.Layers[c.index].Data...)
+	}
+
+	return bestMatch(candidates, vector)
+}
+
+// bestMatch ranks objects against vector and returns the closest one's
+// Value and similarity score, whether or not it clears THRESHOLD
+// (mirroring the historical behavior where a below-threshold match was
+// returned as a best-effort result rather than an empty one).
+func bestMatch(objects []Object, vector []float32) (Val, float32, error) {
+	if len(objects) == 0 {
+		return Val{}, 0, nil
+	}
+
+	simScore := []sim{}
+	for i, obj := range objects {
+		s, err := similarity(obj.Vector, vector)
+		if err != nil {
+			return Val{}, 0, err
+		}
 		simScore = append(simScore, sim{sim: s, index: uint32(i)})
 	}
 
@@ -137,90 +384,111 @@ func Query(
 		return simScore[i].sim > simScore[j].sim
 	})
 
-	// if value --> insert
-	if simScore[0].sim > float32(THRESHOLD) {
-		return layer.Data[simScore[0].index].Value, nil
-	}
-	// return value not found
-	return layer.Data[simScore[0].index].Value, nil
+	best := simScore[0]
+	return objects[best.index].Value, best.sim, nil
 }
 
+// InsertRow embeds and inserts a single value. It is a thin wrapper
+// around InsertRows for callers that don't have a batch on hand.
 func InsertRow(
 	name string, val Val, db *PardusDB,
 ) error {
-	// the insert shall be insert in to the nearest centroid one
-	vector, err := embed.OllamaEmbedding(val.Text, MODEL)
+	_, err := InsertRows(name, []Val{val}, db)
+	return err
+}
 
-	if err != nil {
-		slog.Error(err.Error())
-		return err
+// InsertRows embeds all of vals in a single request to the table's
+// embedder and inserts them one by one, holding the table's write lock
+// for the whole batch so the set of inserts is atomic with respect to
+// concurrent Querys. Batching the embed call is the expensive part to
+// amortize; the per-row bookkeeping below is cheap. It returns the
+// inserted Objects (including their computed vectors) so callers like
+// storage replication can persist exactly what was written.
+func InsertRows(
+	name string, vals []Val, db *PardusDB,
+) ([]Object, error) {
+	if len(vals) == 0 {
+		return nil, nil
 	}
 
-	table, found := db.Tables[name]
+	table, found := db.Table(name)
 	if !found {
-		return (errors.New("table not found"))
+		return nil, errors.New("table not found")
 	}
 
-	layer := &table.Layers[table.pointer]
+	table.mu.Lock()
+	defer table.mu.Unlock()
 
-	obj := Object{
-		Value:  val,
-		Time:   time.Now(),
-		Vector: vector,
+	texts := make([]string, len(vals))
+	for i, v := range vals {
+		texts[i] = v.Text
 	}
 
-	layer.Data = append(layer.Data, obj)
+	vectors, err := table.Embedder.Embed(context.Background(), texts)
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	if len(vectors) != len(vals) {
+		return nil, fmt.Errorf("table %s: expected %d vectors, got %d", name, len(vals), len(vectors))
+	}
+
+	inserted := make([]Object, 0, len(vectors))
+	for i, vector := range vectors {
+		if err := checkDim(table, vector); err != nil {
+			return inserted, err
+		}
 
-	if len(layer.Centriod) == 0 {
-		layer.Centriod = vector
-	} else {
-		layer.Centriod =
-			newCentroid(layer.Centriod, vector, float32(len(vector)))
+		obj := Object{
+			Value:  vals[i],
+			Time:   time.Now(),
+			Vector: vector,
+		}
+
+		if table.index == IndexHNSW {
+			if _, err := table.graph.Insert(vector); err != nil {
+				return inserted, err
+			}
+			table.graphObjs = append(table.graphObjs, obj)
+		} else {
+			table.assign(obj)
+		}
+		table.Count += 1
+
+		inserted = append(inserted, obj)
 	}
 
-	table.pointer = (table.pointer + 1) % table.Capacity
-	table.Count += 1
+	return inserted, nil
+}
 
-	return nil
+// Batch is InsertRows under the name that makes its contract explicit:
+// every Val in vals is embedded with one Embedder.Embed call and then
+// applied while table's write lock is held exactly once, so a
+// concurrent Query never sees a partially-inserted batch.
+func Batch(name string, vals []Val, db *PardusDB) ([]Object, error) {
+	return InsertRows(name, vals, db)
 }
 
+// newCentroid folds point into centroid using the running-mean update
+// c' = c + (x - c) / n, where n is the new number of points assigned to
+// that centroid (including point itself).
 func newCentroid(
-	centroid, point []float32, size float32,
+	centroid, point []float32, n float32,
 ) []float32 {
-	n_c := []float32{}
+	n_c := make([]float32, len(centroid))
 
-	for i := range int(size) {
-		n_c = append(n_c, centroid[i]*size+point[i])
+	for i := range centroid {
+		n_c[i] = centroid[i] + (point[i]-centroid[i])/n
 	}
 	return n_c
 }
 
+// similarity is cosine similarity, computed by pardusdb/vec so this
+// benefits from whichever SIMD kernel (or quantized path) that package
+// picks for the running CPU.
 func similarity(a, b []float32) (float32, error) {
-	size_a := len(a)
-	size_b := len(b)
-
-	if size_a != size_b {
+	if len(a) != len(b) {
 		return 0.0, errors.New("different vector size")
 	}
-
-	dot_product := float32(0.0)
-	norm_a := float32(0.0)
-	norm_b := float32(0.0)
-
-	for i := range size_a {
-		dot_product += a[i] * b[i]
-		norm_a += a[i] * a[i]
-		norm_b += b[i] * b[i]
-	}
-
-	if norm_a == float32(0.0) {
-		norm_a = float32(.00001)
-	}
-
-	if norm_b == float32(0.0) {
-		norm_b = float32(.00001)
-	}
-
-	return dot_product /
-		float32(math.Sqrt(float64(norm_a))) * float32(math.Sqrt(float64(norm_b))), nil
+	return vec.Cosine(a, b), nil
 }
@@ -1,36 +1,67 @@
 package db_test
 
 import (
-	"fmt"
+	"context"
+	"math/rand"
 	"pardusdb/db"
 	"testing"
 )
 
-func TestCreateTable(t *testing.T) {
-	pardus := db.PardusDB{
-		Tables: map[string]*db.Table{},
+// hashEmbedder deterministically maps each distinct text to its own
+// fixed vector (seeded off the text itself), unlike fakeEmbedder in
+// bench_test.go which hands back unrelated random vectors every call.
+// That determinism is what lets this test assert Query returns the
+// exact row it asked for, with no network dependency on a running
+// Ollama server.
+type hashEmbedder struct{ dim int }
+
+func (h *hashEmbedder) Name() string { return "hash" }
+func (h *hashEmbedder) Dim() int     { return h.dim }
+func (h *hashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		var seed int64
+		for _, c := range text {
+			seed = seed*131 + int64(c)
+		}
+		r := rand.New(rand.NewSource(seed))
+		v := make([]float32, h.dim)
+		for d := range v {
+			v[d] = r.Float32()
+		}
+		out[i] = v
 	}
+	return out, nil
+}
+
+func TestCreateTable(t *testing.T) {
+	pardus := db.CreateDB("testing db")
+	embedder := &hashEmbedder{dim: 16}
 
-	table, err := db.CreateTable("testing table", 5, &pardus)
+	table, err := db.CreateTable("testing table", 5, embedder, db.TableOptions{}, &pardus)
 	if err != nil {
-		fmt.Println(err)
-		return
+		t.Fatalf("create table: %v", err)
 	}
 
-	db.InsertRow("testing table", db.Val{Text: "Llamas are the largest lamoid or South American Camelid species. Unlike Old World Camelids, they do not have humps. "}, &pardus)
-	db.InsertRow("testing table", db.Val{Text: "mistral ?"}, &pardus)
-	db.InsertRow("testing table", db.Val{Text: "hello who are you ?"}, &pardus)
-	db.InsertRow("testing table", db.Val{Text: "hello who are you ?"}, &pardus)
-	db.InsertRow("testing table", db.Val{Text: "An artificial intelligence (AI) agent is a system that autonomously performs tasks by designing workflows with available tools. AI agents can encompass a wide range of functions beyond natural"}, &pardus)
-	db.InsertRow("testing table", db.Val{Text: "what the hack ?"}, &pardus)
-	db.InsertRow("testing table", db.Val{Text: "who are you ?"}, &pardus)
-	db.InsertRow("testing table", db.Val{Text: "hello who are you ?"}, &pardus)
-
-	val, err := db.Query("what is ai agent ?", table)
+	rows := []string{
+		"Llamas are the largest lamoid or South American Camelid species. Unlike Old World Camelids, they do not have humps.",
+		"mistral ?",
+		"hello who are you ?",
+		"An artificial intelligence (AI) agent is a system that autonomously performs tasks by designing workflows with available tools.",
+		"what the hack ?",
+		"who are you ?",
+	}
+	for _, text := range rows {
+		if err := db.InsertRow("testing table", db.Val{Text: text}, &pardus); err != nil {
+			t.Fatalf("insert row %q: %v", text, err)
+		}
+	}
 
+	val, err := db.Query(rows[3], table)
 	if err != nil {
-		fmt.Println(err)
+		t.Fatalf("query: %v", err)
+	}
+	if val.Text != rows[3] {
+		t.Fatalf("Query(%q) = %q, want the exact row back", rows[3], val.Text)
 	}
-
-	fmt.Println(val.Text)
 }
@@ -0,0 +1,204 @@
+package db
+
+// This file turns Table.Layers into a real IVF (inverted file) index:
+// the first trainingSize inserts are buffered, k-means seeds one
+// centroid per layer from them, and every insert after that is routed
+// to its nearest centroid instead of round-robin.
+
+const (
+	kmeansIterations    = 10
+	reclusterCheckEvery = 50 // only re-check variance every N inserts into a layer
+)
+
+// assign routes obj to a layer, training or re-clustering the index
+// first if this insert triggers it.
+func (t *Table) assign(obj Object) {
+	if !t.trained {
+		t.trainingBuffer = append(t.trainingBuffer, obj)
+		if uint32(len(t.trainingBuffer)) >= t.trainingSize {
+			t.train(t.trainingBuffer)
+		}
+		return
+	}
+
+	idx := t.nearestCentroid(obj.Vector)
+	t.insertIntoLayer(idx, obj)
+}
+
+// nearestCentroid returns the layer whose centroid is closest to vector.
+func (t *Table) nearestCentroid(vector []float32) uint32 {
+	best := uint32(0)
+	bestSim := float32(-2) // cosine similarity is in [-1, 1]
+
+	for i := range t.Layers {
+		s, err := similarity(t.Layers[i].Centriod, vector)
+		if err != nil {
+			continue
+		}
+		if s > bestSim {
+			bestSim = s
+			best = uint32(i)
+		}
+	}
+
+	return best
+}
+
+func (t *Table) insertIntoLayer(idx uint32, obj Object) {
+	layer := &t.Layers[idx]
+	layer.Data = append(layer.Data, obj)
+
+	n := float32(len(layer.Data))
+	if n == 1 {
+		layer.Centriod = append([]float32(nil), obj.Vector...)
+	} else {
+		layer.Centriod = newCentroid(layer.Centriod, obj.Vector, n)
+	}
+
+	t.maybeRecluster(idx)
+}
+
+// train runs k-means over buffered objects to seed one centroid per
+// layer, then assigns each object to its nearest new centroid.
+func (t *Table) train(buffer []Object) {
+	vectors := make([][]float32, len(buffer))
+	for i, o := range buffer {
+		vectors[i] = o.Vector
+	}
+
+	centroids, assignments := kmeans(vectors, int(t.Capacity), kmeansIterations)
+
+	layers := make([]Layer, len(centroids))
+	for i := range layers {
+		layers[i].Centriod = centroids[i]
+	}
+	for i, obj := range buffer {
+		layers[assignments[i]].Data = append(layers[assignments[i]].Data, obj)
+	}
+
+	t.Layers = layers
+	t.trained = true
+	t.trainingBuffer = nil
+}
+
+// maybeRecluster checks whether layer idx's intra-cluster variance has
+// grown past varianceThreshold and, if so, retrains the whole index
+// from its current contents. Checked only every reclusterCheckEvery
+// inserts into the layer, since the variance scan is O(layer size).
+func (t *Table) maybeRecluster(idx uint32) {
+	if t.varianceThreshold <= 0 {
+		return
+	}
+
+	layer := &t.Layers[idx]
+	if len(layer.Data) == 0 || len(layer.Data)%reclusterCheckEvery != 0 {
+		return
+	}
+
+	if intraClusterVariance(layer) > t.varianceThreshold {
+		t.retrain()
+	}
+}
+
+// intraClusterVariance is the mean squared cosine distance (1 -
+// similarity) of a layer's points from its own centroid.
+func intraClusterVariance(layer *Layer) float32 {
+	if len(layer.Data) == 0 {
+		return 0
+	}
+
+	var sum float32
+	for _, obj := range layer.Data {
+		s, err := similarity(layer.Centriod, obj.Vector)
+		if err != nil {
+			continue
+		}
+		d := 1 - s
+		sum += d * d
+	}
+
+	return sum / float32(len(layer.Data))
+}
+
+// retrain gathers every object currently indexed and re-seeds the
+// centroids from scratch, the same as the initial training pass.
+func (t *Table) retrain() {
+	all := make([]Object, 0, t.Count)
+	for _, l := range t.Layers {
+		all = append(all, l.Data...)
+	}
+	t.train(all)
+}
+
+// kmeans clusters vectors into k groups by cosine similarity (Lloyd's
+// algorithm, evenly-spaced seeding) and returns the resulting centroids
+// plus each vector's assigned cluster index.
+func kmeans(vectors [][]float32, k int, iterations int) ([][]float32, []int) {
+	n := len(vectors)
+	if n == 0 || k <= 0 {
+		return nil, nil
+	}
+	if k > n {
+		k = n
+	}
+	dim := len(vectors[0])
+
+	centroids := make([][]float32, k)
+	step := n / k
+	for i := range k {
+		centroids[i] = append([]float32(nil), vectors[i*step]...)
+	}
+
+	assignments := make([]int, n)
+
+	for iter := 0; iter < iterations; iter++ {
+		changed := false
+
+		for i, v := range vectors {
+			best := 0
+			bestSim := float32(-2)
+			for c := range centroids {
+				s, err := similarity(centroids[c], v)
+				if err != nil {
+					continue
+				}
+				if s > bestSim {
+					bestSim = s
+					best = c
+				}
+			}
+			if assignments[i] != best {
+				changed = true
+			}
+			assignments[i] = best
+		}
+
+		sums := make([][]float32, k)
+		counts := make([]int, k)
+		for c := range sums {
+			sums[c] = make([]float32, dim)
+		}
+		for i, v := range vectors {
+			c := assignments[i]
+			counts[c]++
+			for d := range v {
+				sums[c][d] += v[d]
+			}
+		}
+		for c := range centroids {
+			if counts[c] == 0 {
+				continue // keep the previous centroid for an empty cluster
+			}
+			for d := range sums[c] {
+				sums[c][d] /= float32(counts[c])
+			}
+			centroids[c] = sums[c]
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	return centroids, assignments
+}
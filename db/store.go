@@ -0,0 +1,286 @@
+package db
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"pardusdb/embed"
+	"pardusdb/storage"
+)
+
+// Handle is a PardusDB backed by an on-disk storage.Store. Every mutation
+// made through a Handle's methods (CreateTable, InsertRow/InsertRows) is
+// durably logged before it returns, so the database survives a restart.
+type Handle struct {
+	*PardusDB
+	store *storage.Store
+}
+
+// Open opens (creating if needed) the database persisted under dir,
+// replaying its WAL onto its last snapshot to rebuild an in-memory
+// PardusDB. The database name is derived from the directory name.
+//
+// A table whose Embedder was built from an embed.Spec (see
+// TableOptions.EmbedderSpec) gets that Embedder rebuilt automatically.
+// A table created with a caller-supplied Embedder that didn't come from
+// a Spec (e.g. a test stub) comes back with a nil Embedder instead;
+// call BindEmbedder for those before inserting or querying.
+func Open(dir string, opts storage.Options) (*Handle, error) {
+	st, tables, records, err := storage.Open(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	pdb := CreateDB(filepath.Base(dir))
+	h := &Handle{PardusDB: &pdb, store: st}
+
+	for name, table := range tables {
+		h.restoreTable(name, table)
+	}
+	for _, rec := range records {
+		h.applyRecord(rec)
+	}
+
+	st.SetSnapshotFunc(h.snapshot)
+
+	return h, nil
+}
+
+// Close stops background compaction and closes the underlying WAL.
+func (h *Handle) Close() error {
+	return h.store.Close()
+}
+
+// BindEmbedder attaches the live Embedder a restored table should use
+// for future inserts and queries.
+func (h *Handle) BindEmbedder(table string, embedder embed.Embedder) error {
+	t, found := h.Tables[table]
+	if !found {
+		return fmt.Errorf("table %s not found", table)
+	}
+	t.Embedder = embedder
+	return nil
+}
+
+// CreateTable creates a table and durably logs the creation.
+func (h *Handle) CreateTable(name string, cap uint32, embedder embed.Embedder, opts TableOptions) (*Table, error) {
+	t, err := CreateTable(name, cap, embedder, opts, h.PardusDB)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.store.Append(storage.Record{
+		Op:       storage.OpCreateTable,
+		Table:    name,
+		Capacity: cap,
+		Embedder: toStorageSpec(t.EmbedderSpec),
+	}); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// InsertRow embeds, inserts, and durably logs a single value.
+func (h *Handle) InsertRow(table string, val Val) error {
+	return h.InsertRows(table, []Val{val})
+}
+
+// InsertRows embeds, inserts, and durably logs a batch of values.
+func (h *Handle) InsertRows(table string, vals []Val) error {
+	inserted, err := InsertRows(table, vals, h.PardusDB)
+	for _, obj := range inserted {
+		if walErr := h.store.Append(storage.Record{
+			Op:       storage.OpInsert,
+			Table:    table,
+			Text:     obj.Value.Text,
+			MetaData: obj.Value.MetaData,
+			Time:     obj.Time,
+			Vector:   obj.Vector,
+		}); walErr != nil {
+			return walErr
+		}
+	}
+	return err
+}
+
+// Batch embeds, inserts, and durably logs vals against table in one
+// atomic write-locked pass (see Batch in db.go).
+func (h *Handle) Batch(table string, vals []Val) error {
+	return h.InsertRows(table, vals)
+}
+
+// Compact forces an immediate snapshot + WAL truncation, on top of
+// whatever automatic compaction policy the Store was opened with. It
+// does nothing while snapshot refuses to build one (see snapshot).
+func (h *Handle) Compact() error {
+	return h.store.Compact()
+}
+
+// restoreTable rebuilds a table straight from its snapshot. snapshot
+// refuses to run while any flat table still has rows in its training
+// buffer, so a snapshotted table either has trained layers, used as-is,
+// or holds no rows yet, in which case it comes back untrained and
+// trains on its own as rows arrive.
+//
+// This only rebuilds the IndexFlat (IVF) layout: snapshots don't yet
+// capture which index kind a table used or an HNSW graph's edges, so a
+// table created with IndexHNSW comes back as IndexFlat after a restart.
+// Rebuilding the graph from the persisted rows would work too, just
+// isn't wired up yet.
+func (h *Handle) restoreTable(name string, snap storage.SnapshotTable) {
+	layers := make([]Layer, len(snap.Layers))
+	total := uint32(0)
+
+	for i, l := range snap.Layers {
+		objs := make([]Object, len(l.Objects))
+		for j, o := range l.Objects {
+			objs[j] = Object{
+				Value:  Val{Text: o.Text, MetaData: o.MetaData},
+				Time:   o.Time,
+				Vector: o.Vector,
+			}
+		}
+		layers[i] = Layer{Data: objs, Centriod: l.Centroid}
+		total += uint32(len(objs))
+	}
+
+	t := &Table{
+		Name:         name,
+		Capacity:     snap.Capacity,
+		Count:        total,
+		Layers:       layers,
+		Nprobe:       1,
+		trained:      len(layers) > 0,
+		trainingSize: snap.Capacity * defaultTrainingMultiplier,
+		EmbedderSpec: fromStorageSpec(snap.Embedder),
+	}
+	t.Embedder = buildEmbedder(t.EmbedderSpec, name)
+
+	h.Tables[name] = t
+}
+
+func (h *Handle) applyRecord(rec storage.Record) {
+	switch rec.Op {
+	case storage.OpCreateTable:
+		if _, found := h.Tables[rec.Table]; found {
+			return
+		}
+		spec := fromStorageSpec(rec.Embedder)
+		h.Tables[rec.Table] = &Table{
+			Name:         rec.Table,
+			Capacity:     rec.Capacity,
+			Layers:       []Layer{},
+			Nprobe:       1,
+			trainingSize: rec.Capacity * defaultTrainingMultiplier,
+			EmbedderSpec: spec,
+			Embedder:     buildEmbedder(spec, rec.Table),
+		}
+
+	case storage.OpInsert:
+		table, found := h.Tables[rec.Table]
+		if !found {
+			return
+		}
+
+		table.assign(Object{
+			Value:  Val{Text: rec.Text, MetaData: rec.MetaData},
+			Time:   rec.Time,
+			Vector: rec.Vector,
+		})
+		table.Count++
+	}
+}
+
+// snapshot builds the current state to persist, unless some table
+// can't be fully represented in one: an IndexHNSW table's rows live in
+// graph/graphObjs, not Layers, and a flat table that hasn't finished
+// training keeps its rows in trainingBuffer, so a snapshot would capture
+// none of those rows. Compacting anyway would then truncate the WAL that
+// holds the only durable copy of them, so refuse to compact at all while
+// such a table exists, and the WAL stays the source of truth for every
+// table in the meantime.
+func (h *Handle) snapshot() (map[string]storage.SnapshotTable, bool) {
+	for _, t := range h.Tables {
+		if t.index == IndexHNSW || len(t.trainingBuffer) > 0 {
+			return nil, false
+		}
+	}
+
+	out := make(map[string]storage.SnapshotTable, len(h.Tables))
+
+	for name, t := range h.Tables {
+		layers := make([]storage.SnapshotLayer, len(t.Layers))
+		for i, l := range t.Layers {
+			objs := make([]storage.SnapshotObject, len(l.Data))
+			for j, o := range l.Data {
+				objs[j] = storage.SnapshotObject{
+					Text:     o.Value.Text,
+					MetaData: o.Value.MetaData,
+					Time:     o.Time,
+					Vector:   o.Vector,
+				}
+			}
+			layers[i] = storage.SnapshotLayer{Centroid: l.Centriod, Objects: objs}
+		}
+		out[name] = storage.SnapshotTable{
+			Name:     name,
+			Capacity: t.Capacity,
+			Embedder: toStorageSpec(t.EmbedderSpec),
+			Layers:   layers,
+		}
+	}
+
+	return out, true
+}
+
+// buildEmbedder rebuilds the Embedder a restored table should use from
+// its persisted spec. A zero spec (Backend == "") means the table's
+// Embedder never came from one; callers must BindEmbedder it by hand,
+// same as before EmbedderSpec existed. A non-zero spec that fails to
+// build (e.g. a "local" backend in a binary built without
+// pardusdb_local_inference) is logged and left nil rather than failing
+// the whole restore over one table.
+func buildEmbedder(spec embed.Spec, table string) embed.Embedder {
+	if spec.Backend == "" {
+		return nil
+	}
+	embedder, err := spec.Build()
+	if err != nil {
+		slog.Error("rebuild embedder from persisted spec", "table", table, "error", err)
+		return nil
+	}
+	return embedder
+}
+
+// toStorageSpec and fromStorageSpec convert between embed.Spec and its
+// storage-package mirror (storage.EmbedderSpec), the same way
+// restoreTable/snapshot already convert Object/Layer/Table.
+func toStorageSpec(spec embed.Spec) storage.EmbedderSpec {
+	return storage.EmbedderSpec{
+		Backend:    string(spec.Backend),
+		Model:      spec.Model,
+		Dim:        spec.Dim,
+		Addr:       spec.Addr,
+		BaseURL:    spec.Config.BaseURL,
+		APIKey:     spec.Config.APIKey,
+		Timeout:    spec.Config.Timeout,
+		MaxRetries: spec.Config.MaxRetries,
+	}
+}
+
+func fromStorageSpec(spec storage.EmbedderSpec) embed.Spec {
+	return embed.Spec{
+		Backend: embed.Backend(spec.Backend),
+		Model:   spec.Model,
+		Dim:     spec.Dim,
+		Addr:    spec.Addr,
+		Config: embed.Config{
+			BaseURL:    spec.BaseURL,
+			APIKey:     spec.APIKey,
+			Timeout:    spec.Timeout,
+			MaxRetries: spec.MaxRetries,
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package db_test
+
+import (
+	"testing"
+
+	"pardusdb/db"
+	"pardusdb/embed"
+	"pardusdb/storage"
+)
+
+// TestReopenRebuildsEmbedder checks that a table created with an
+// EmbedderSpec comes back from a restart with its Embedder rebuilt
+// automatically from the WAL, instead of the nil Embedder that used to
+// panic on the first insert after a reopen (see
+// db.TableOptions.EmbedderSpec). It builds the Embedder but never calls
+// Embed, so it needs no live backend to run.
+func TestReopenRebuildsEmbedder(t *testing.T) {
+	dir := t.TempDir()
+	opts := storage.Options{}
+
+	spec := embed.Spec{Backend: embed.BackendOllama, Model: "nomic-embed-text", Dim: 8}
+	embedder, err := spec.Build()
+	if err != nil {
+		t.Fatalf("build embedder: %v", err)
+	}
+
+	h, err := db.Open(dir, opts)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err := h.CreateTable("t", 4, embedder, db.TableOptions{EmbedderSpec: spec}); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	h2, err := db.Open(dir, opts)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer h2.Close()
+
+	table, found := h2.Table("t")
+	if !found {
+		t.Fatalf("table %q missing after reopen", "t")
+	}
+	if table.Embedder == nil {
+		t.Fatalf("table.Embedder is nil after reopen, want it rebuilt from the persisted spec")
+	}
+	if table.Embedder.Dim() != spec.Dim {
+		t.Fatalf("table.Embedder.Dim() = %d, want %d", table.Embedder.Dim(), spec.Dim)
+	}
+}
+
+// TestReopenRebuildsEmbedderFromSnapshot is the same check, but forces a
+// compaction (snapshot + WAL truncation) before reopening, so it covers
+// restoreTable's snapshot path instead of applyRecord's WAL-replay path.
+func TestReopenRebuildsEmbedderFromSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	opts := storage.Options{CompactEvery: 1}
+
+	spec := embed.Spec{Backend: embed.BackendOllama, Model: "nomic-embed-text", Dim: 8}
+	embedder, err := spec.Build()
+	if err != nil {
+		t.Fatalf("build embedder: %v", err)
+	}
+
+	h, err := db.Open(dir, opts)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err := h.CreateTable("t", 4, embedder, db.TableOptions{EmbedderSpec: spec}); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	h2, err := db.Open(dir, opts)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer h2.Close()
+
+	table, found := h2.Table("t")
+	if !found {
+		t.Fatalf("table %q missing after reopen", "t")
+	}
+	if table.Embedder == nil {
+		t.Fatalf("table.Embedder is nil after reopen from snapshot, want it rebuilt from the persisted spec")
+	}
+}
@@ -0,0 +1,46 @@
+package embed
+
+import "time"
+
+// Config holds the connection settings shared by the HTTP-based backends
+// (Ollama, OpenAI-compatible). Fields left zero fall back to sane defaults.
+type Config struct {
+	BaseURL    string
+	APIKey     string
+	Timeout    time.Duration
+	MaxRetries int
+}
+
+const (
+	defaultTimeout    = 30 * time.Second
+	defaultMaxRetries = 2
+)
+
+func (c Config) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return defaultTimeout
+	}
+	return c.Timeout
+}
+
+func (c Config) maxRetries() int {
+	if c.MaxRetries < 0 {
+		return 0
+	}
+	if c.MaxRetries == 0 {
+		return defaultMaxRetries
+	}
+	return c.MaxRetries
+}
+
+// withRetry calls fn up to maxRetries+1 times, returning the last error if
+// every attempt fails.
+func withRetry(maxRetries int, fn func() error) error {
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
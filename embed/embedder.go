@@ -0,0 +1,18 @@
+package embed
+
+import "context"
+
+// Embedder turns text into vectors. Implementations talk to a specific
+// backend (Ollama, an OpenAI-compatible API, a local GGUF model, a LocalAI
+// gRPC backend, ...) but are otherwise interchangeable from the caller's
+// point of view.
+type Embedder interface {
+	// Embed returns one vector per input string, in the same order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+
+	// Dim returns the dimensionality of the vectors this embedder produces.
+	Dim() int
+
+	// Name identifies the embedder, e.g. for logging or error messages.
+	Name() string
+}
@@ -0,0 +1,52 @@
+//go:build pardusdb_local_inference
+
+// LocalEmbedder lives behind the pardusdb_local_inference build tag
+// along with pardusdb/inference itself - see that package's doc comment
+// for why it isn't part of the default build.
+package embed
+
+import (
+	"context"
+	"fmt"
+	"pardusdb/inference"
+)
+
+// LocalEmbedder runs a local GGUF/BERT model through the cgo inference
+// bridge, for deployments that don't want a network hop per embed call.
+type LocalEmbedder struct {
+	path  string
+	dim   int
+	model *inference.File
+}
+
+// NewLocalEmbedder opens the GGUF model at path and checks its header.
+func NewLocalEmbedder(path string, dim int) (*LocalEmbedder, error) {
+	f := inference.FileReader(path)
+	if f == nil {
+		return nil, fmt.Errorf("local embed: could not open model file %q", path)
+	}
+	inference.GGUFCheck(f)
+
+	return &LocalEmbedder{path: path, dim: dim, model: f}, nil
+}
+
+func (l *LocalEmbedder) Name() string { return "local:" + l.path }
+
+func (l *LocalEmbedder) Dim() int { return l.dim }
+
+func (l *LocalEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v, err := inference.Embed(l.model, text, l.dim)
+		if err != nil {
+			return nil, fmt.Errorf("local embed: %v", err)
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+// Close releases the underlying model file handle.
+func (l *LocalEmbedder) Close() {
+	inference.CloseFileReader(l.model)
+}
@@ -0,0 +1,10 @@
+//go:build pardusdb_local_inference
+
+package embed
+
+// buildLocal is the pardusdb_local_inference-tagged half of
+// Spec.Build's BackendLocal case; see local_build_stub.go for the
+// default build's version.
+func buildLocal(spec Spec) (Embedder, error) {
+	return NewLocalEmbedder(spec.Addr, spec.Dim)
+}
@@ -0,0 +1,13 @@
+//go:build !pardusdb_local_inference
+
+package embed
+
+import "fmt"
+
+// buildLocal stands in for BackendLocal when the binary wasn't built
+// with -tags pardusdb_local_inference, so Spec.Build fails with a clear
+// error instead of the package failing to compile at all (see
+// pardusdb/inference's doc comment for why that tag exists).
+func buildLocal(spec Spec) (Embedder, error) {
+	return nil, fmt.Errorf("embed: backend %q requires building with -tags pardusdb_local_inference", BackendLocal)
+}
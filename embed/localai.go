@@ -0,0 +1,101 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+)
+
+// localAICodecName is both the encoding.Codec's registered name and the
+// content-subtype selected via grpc.CallContentSubtype, so Invoke below
+// actually uses jsonCodec instead of falling back to the vendored
+// proto codec (which localAIRequest/localAIResponse don't implement).
+// Wire-compatibility with LocalAI itself would mean pulling in its
+// generated pb.go; this mirrors its backend.proto shape (one Embedding
+// RPC taking a batch of strings) closely enough to swap in the real
+// codec later.
+const localAICodecName = "pardusdb-embed"
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec lets LocalAIEmbedder speak its minimal gRPC contract with
+// plain JSON instead of protobuf, since localAIRequest/localAIResponse
+// aren't generated proto.Message types.
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return localAICodecName }
+
+func (jsonCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type localAIRequest struct {
+	Model string   `json:"model"`
+	Texts []string `json:"texts"`
+}
+
+type localAIResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// LocalAIEmbedder calls a LocalAI-style gRPC embedding backend.
+type LocalAIEmbedder struct {
+	cfg   Config
+	model string
+	dim   int
+	conn  *grpc.ClientConn
+}
+
+// NewLocalAIEmbedder dials addr and returns an Embedder backed by a
+// LocalAI-style gRPC backend process.
+func NewLocalAIEmbedder(addr, model string, dim int, cfg Config) (*LocalAIEmbedder, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("localai: dial %s: %v", addr, err)
+	}
+
+	return &LocalAIEmbedder{cfg: cfg, model: model, dim: dim, conn: conn}, nil
+}
+
+func (l *LocalAIEmbedder) Name() string { return "localai:" + l.model }
+
+func (l *LocalAIEmbedder) Dim() int { return l.dim }
+
+func (l *LocalAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("embed: no texts given")
+	}
+
+	if l.cfg.timeout() > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.cfg.timeout())
+		defer cancel()
+	}
+
+	req := &localAIRequest{Model: l.model, Texts: texts}
+	resp := &localAIResponse{}
+
+	err := withRetry(l.cfg.maxRetries(), func() error {
+		return l.conn.Invoke(ctx, "/localai.Backend/Embedding", req, resp, grpc.CallContentSubtype(localAICodecName))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("localai: %v", err)
+	}
+
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("localai: expected %d embeddings, got %d", len(texts), len(resp.Embeddings))
+	}
+
+	return resp.Embeddings, nil
+}
+
+// Close tears down the gRPC connection.
+func (l *LocalAIEmbedder) Close() error {
+	return l.conn.Close()
+}
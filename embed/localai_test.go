@@ -0,0 +1,74 @@
+package embed
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeLocalAIEmbedding implements just enough of LocalAI's gRPC contract
+// to exercise LocalAIEmbedder end to end: decode the request with
+// whatever codec the client selected, and hand back one deterministic
+// vector per input text.
+func fakeLocalAIEmbedding(_ any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+	req := new(localAIRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+
+	resp := &localAIResponse{Embeddings: make([][]float32, len(req.Texts))}
+	for i, text := range req.Texts {
+		resp.Embeddings[i] = []float32{float32(len(text)), 1}
+	}
+	return resp, nil
+}
+
+// TestLocalAIEmbedderRoundTrip dials a real in-process grpc.Server and
+// checks that LocalAIEmbedder.Embed actually gets a response back. This
+// is the regression test for the codec bug: before jsonCodec was
+// registered and selected via grpc.CallContentSubtype, conn.Invoke failed
+// with "proto: failed to marshal, message is *embed.localAIRequest, want
+// proto.Message" instead of ever reaching the server.
+func TestLocalAIEmbedderRoundTrip(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	defer lis.Close()
+
+	srv := grpc.NewServer()
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "localai.Backend",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "Embedding", Handler: fakeLocalAIEmbedding},
+		},
+	}, nil)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	embedder := &LocalAIEmbedder{model: "fake-model", dim: 2, conn: conn}
+
+	vectors, err := embedder.Embed(context.Background(), []string{"hi", "hello"})
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if len(vectors) != 2 {
+		t.Fatalf("got %d vectors, want 2", len(vectors))
+	}
+	if vectors[0][0] != 2 || vectors[1][0] != 5 {
+		t.Fatalf("vectors = %v, want [[2 1] [5 1]]", vectors)
+	}
+}
@@ -0,0 +1,102 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const defaultOllamaBaseURL = "http://localhost:11434"
+
+// OllamaEmbedder talks to an Ollama server's /api/embed endpoint.
+type OllamaEmbedder struct {
+	cfg    Config
+	model  string
+	dim    int
+	client *http.Client
+}
+
+type ollamaRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// NewOllamaEmbedder creates an Embedder backed by an Ollama server. dim is
+// the known output dimensionality of model, used for table-level validation
+// before the first real embedding call comes back.
+func NewOllamaEmbedder(model string, dim int, cfg Config) *OllamaEmbedder {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultOllamaBaseURL
+	}
+	return &OllamaEmbedder{
+		cfg:    cfg,
+		model:  model,
+		dim:    dim,
+		client: &http.Client{Timeout: cfg.timeout()},
+	}
+}
+
+func (o *OllamaEmbedder) Name() string { return "ollama:" + o.model }
+
+func (o *OllamaEmbedder) Dim() int { return o.dim }
+
+func (o *OllamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("embed: no texts given")
+	}
+
+	reqBody, err := json.Marshal(ollamaRequest{Model: o.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request body: %v", err)
+	}
+
+	var out ollamaResponse
+	err = withRetry(o.cfg.maxRetries(), func() error {
+		req, err := http.NewRequestWithContext(ctx, "POST", o.cfg.BaseURL+"/api/embed", bytes.NewReader(reqBody))
+		if err != nil {
+			return fmt.Errorf("error creating request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if o.cfg.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+o.cfg.APIKey)
+		}
+
+		resp, err := o.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("error sending request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("error reading response: %v", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("ollama: status %d: %s", resp.StatusCode, body)
+		}
+
+		out = ollamaResponse{}
+		if err := json.Unmarshal(body, &out); err != nil {
+			return fmt.Errorf("error parsing response: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama: expected %d embeddings, got %d", len(texts), len(out.Embeddings))
+	}
+
+	return out.Embeddings, nil
+}
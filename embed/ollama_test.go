@@ -0,0 +1,75 @@
+package embed_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pardusdb/embed"
+)
+
+func TestOllamaEmbedderRoundTrip(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("path = %s, want /api/embed", r.URL.Path)
+		}
+
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+
+		embeddings := make([][]float32, len(req.Input))
+		for i := range req.Input {
+			embeddings[i] = []float32{float32(i), 0.5}
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"model":      req.Model,
+			"embeddings": embeddings,
+		})
+	}))
+	defer ts.Close()
+
+	e := embed.NewOllamaEmbedder("nomic-embed-text", 2, embed.Config{BaseURL: ts.URL})
+
+	vectors, err := e.Embed(t.Context(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if len(vectors) != 2 {
+		t.Fatalf("got %d vectors, want 2", len(vectors))
+	}
+	if vectors[1][0] != 1 {
+		t.Fatalf("vectors[1] = %v, want [1 0.5]", vectors[1])
+	}
+}
+
+func TestOllamaEmbedderMismatchedCount(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"embeddings": [][]float32{{1, 2}}})
+	}))
+	defer ts.Close()
+
+	e := embed.NewOllamaEmbedder("nomic-embed-text", 2, embed.Config{BaseURL: ts.URL, MaxRetries: -1})
+
+	if _, err := e.Embed(t.Context(), []string{"a", "b"}); err == nil {
+		t.Fatalf("expected error for mismatched embedding count, got nil")
+	}
+}
+
+func TestOllamaEmbedderServerError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	e := embed.NewOllamaEmbedder("nomic-embed-text", 2, embed.Config{BaseURL: ts.URL, MaxRetries: -1})
+
+	if _, err := e.Embed(t.Context(), []string{"a"}); err == nil {
+		t.Fatalf("expected error for 500 response, got nil")
+	}
+}
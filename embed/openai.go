@@ -0,0 +1,110 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const defaultOpenAIBaseURL = "https://api.openai.com"
+
+// OpenAIEmbedder talks to an OpenAI-compatible /v1/embeddings endpoint
+// (OpenAI itself, or any server implementing the same contract).
+type OpenAIEmbedder struct {
+	cfg    Config
+	model  string
+	dim    int
+	client *http.Client
+}
+
+type openAIRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type openAIResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+		Index     int       `json:"index"`
+	} `json:"data"`
+}
+
+func NewOpenAIEmbedder(model string, dim int, cfg Config) *OpenAIEmbedder {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultOpenAIBaseURL
+	}
+	return &OpenAIEmbedder{
+		cfg:    cfg,
+		model:  model,
+		dim:    dim,
+		client: &http.Client{Timeout: cfg.timeout()},
+	}
+}
+
+func (o *OpenAIEmbedder) Name() string { return "openai:" + o.model }
+
+func (o *OpenAIEmbedder) Dim() int { return o.dim }
+
+func (o *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("embed: no texts given")
+	}
+
+	reqBody, err := json.Marshal(openAIRequest{Model: o.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request body: %v", err)
+	}
+
+	var out openAIResponse
+	err = withRetry(o.cfg.maxRetries(), func() error {
+		req, err := http.NewRequestWithContext(ctx, "POST", o.cfg.BaseURL+"/v1/embeddings", bytes.NewReader(reqBody))
+		if err != nil {
+			return fmt.Errorf("error creating request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if o.cfg.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+o.cfg.APIKey)
+		}
+
+		resp, err := o.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("error sending request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("error reading response: %v", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("openai: status %d: %s", resp.StatusCode, body)
+		}
+
+		out = openAIResponse{}
+		if err := json.Unmarshal(body, &out); err != nil {
+			return fmt.Errorf("error parsing response: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out.Data) != len(texts) {
+		return nil, fmt.Errorf("openai: expected %d embeddings, got %d", len(texts), len(out.Data))
+	}
+
+	vectors := make([][]float32, len(out.Data))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("openai: embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+
+	return vectors, nil
+}
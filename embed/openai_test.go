@@ -0,0 +1,67 @@
+package embed_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pardusdb/embed"
+)
+
+func TestOpenAIEmbedderRoundTrip(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("path = %s, want /v1/embeddings", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+		}
+
+		var req struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+
+		// Return the embeddings out of order to check that OpenAIEmbedder
+		// places each one back at its reported Index rather than relying
+		// on response order matching request order.
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": []map[string]any{
+				{"embedding": []float32{1, 1}, "index": 1},
+				{"embedding": []float32{0, 0}, "index": 0},
+			},
+		})
+	}))
+	defer ts.Close()
+
+	e := embed.NewOpenAIEmbedder("text-embedding-3-small", 2, embed.Config{BaseURL: ts.URL, APIKey: "secret"})
+
+	vectors, err := e.Embed(t.Context(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if len(vectors) != 2 {
+		t.Fatalf("got %d vectors, want 2", len(vectors))
+	}
+	if vectors[0][0] != 0 || vectors[1][0] != 1 {
+		t.Fatalf("vectors = %v, want [[0 0] [1 1]]", vectors)
+	}
+}
+
+func TestOpenAIEmbedderIndexOutOfRange(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": []map[string]any{{"embedding": []float32{1, 1}, "index": 5}},
+		})
+	}))
+	defer ts.Close()
+
+	e := embed.NewOpenAIEmbedder("text-embedding-3-small", 2, embed.Config{BaseURL: ts.URL, MaxRetries: -1})
+
+	if _, err := e.Embed(t.Context(), []string{"a"}); err == nil {
+		t.Fatalf("expected error for out-of-range index, got nil")
+	}
+}
@@ -0,0 +1,48 @@
+package embed
+
+import "fmt"
+
+// Backend selects which Embedder implementation a Spec builds.
+type Backend string
+
+const (
+	BackendOllama  Backend = "ollama"
+	BackendOpenAI  Backend = "openai"
+	BackendLocal   Backend = "local"
+	BackendLocalAI Backend = "localai"
+)
+
+// Spec is everything needed to reconstruct an Embedder: not just which
+// backend and model, but the connection details it needs to reach that
+// backend again. Callers that want a table's Embedder to survive a
+// restart (see pardusdb/db.TableOptions.EmbedderSpec) keep the Spec
+// around instead of just the built Embedder.
+type Spec struct {
+	Backend Backend
+	Model   string
+	Dim     int
+
+	// Addr is the dial target for BackendLocalAI, or the model file
+	// path for BackendLocal. Unused by the HTTP-based backends, which
+	// use Config.BaseURL instead.
+	Addr string
+
+	Config Config
+}
+
+// Build constructs the Embedder spec describes. BackendLocal requires
+// the binary to have been built with -tags pardusdb_local_inference.
+func (spec Spec) Build() (Embedder, error) {
+	switch spec.Backend {
+	case BackendOllama, "":
+		return NewOllamaEmbedder(spec.Model, spec.Dim, spec.Config), nil
+	case BackendOpenAI:
+		return NewOpenAIEmbedder(spec.Model, spec.Dim, spec.Config), nil
+	case BackendLocalAI:
+		return NewLocalAIEmbedder(spec.Addr, spec.Model, spec.Dim, spec.Config)
+	case BackendLocal:
+		return buildLocal(spec)
+	default:
+		return nil, fmt.Errorf("embed: unknown backend %q", spec.Backend)
+	}
+}
@@ -0,0 +1,362 @@
+// Package hnsw implements Hierarchical Navigable Small World graphs, an
+// approximate nearest-neighbor index that trades a small amount of
+// recall for much better than linear query time on large vector sets.
+//
+// It is deliberately standalone (no dependency on pardusdb/db) so it can
+// be unit tested and benchmarked on its own; pardusdb/db wires it in as
+// an alternative to the flat/IVF layer scan.
+package hnsw
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// Distance selects the metric used to compare vectors. Whichever one is
+// chosen, the graph always treats "smaller computed distance" as
+// "closer", so cosine and inner product are converted accordingly.
+type Distance int
+
+const (
+	Cosine Distance = iota
+	L2
+	InnerProduct
+)
+
+// Options tunes graph construction and search. Zero values fall back to
+// the defaults below.
+type Options struct {
+	M              int // neighbors per node above level 0
+	Mmax0          int // neighbors per node at level 0; 0 -> 2*M
+	EfConstruction int // candidate list size while inserting
+	EfSearch       int // candidate list size while searching
+	Distance       Distance
+}
+
+const (
+	defaultM              = 16
+	defaultEfConstruction = 200
+	defaultEfSearch       = 50
+)
+
+func (o Options) withDefaults() Options {
+	if o.M <= 0 {
+		o.M = defaultM
+	}
+	if o.Mmax0 <= 0 {
+		o.Mmax0 = 2 * o.M
+	}
+	if o.EfConstruction <= 0 {
+		o.EfConstruction = defaultEfConstruction
+	}
+	if o.EfSearch <= 0 {
+		o.EfSearch = defaultEfSearch
+	}
+	return o
+}
+
+type node struct {
+	vector    []float32
+	neighbors [][]int // neighbors[level] = neighbor ids at that level
+}
+
+// Graph is an HNSW index over float32 vectors, identified by the
+// insertion-order id Insert returns.
+type Graph struct {
+	opts Options
+	mL   float64
+
+	mu       sync.RWMutex
+	nodes    []node
+	entry    int
+	maxLevel int
+}
+
+// New creates an empty graph with the given options.
+func New(opts Options) *Graph {
+	opts = opts.withDefaults()
+	return &Graph{
+		opts:     opts,
+		mL:       1 / math.Log(float64(opts.M)),
+		entry:    -1,
+		maxLevel: -1,
+	}
+}
+
+// Dim returns the dimensionality of the vectors stored so far, or 0 if
+// the graph is empty.
+func (g *Graph) Dim() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if len(g.nodes) == 0 {
+		return 0
+	}
+	return len(g.nodes[0].vector)
+}
+
+// randomLevel draws a level from an exponential distribution, as in the
+// original HNSW paper: floor(-ln(U) * mL).
+func (g *Graph) randomLevel() int {
+	u := rand.Float64()
+	for u == 0 {
+		u = rand.Float64()
+	}
+	return int(math.Floor(-math.Log(u) * g.mL))
+}
+
+// Insert adds vector to the graph and returns its node id.
+func (g *Graph) Insert(vector []float32) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.nodes) > 0 && len(vector) != len(g.nodes[0].vector) {
+		return 0, fmt.Errorf("hnsw: expected %d-dim vector, got %d", len(g.nodes[0].vector), len(vector))
+	}
+
+	level := g.randomLevel()
+	id := len(g.nodes)
+	g.nodes = append(g.nodes, node{
+		vector:    vector,
+		neighbors: make([][]int, level+1),
+	})
+
+	if g.entry == -1 {
+		g.entry = id
+		g.maxLevel = level
+		return id, nil
+	}
+
+	entryPoints := []candidate{{id: g.entry, dist: g.dist(vector, g.nodes[g.entry].vector)}}
+
+	for lc := g.maxLevel; lc > level; lc-- {
+		entryPoints = g.searchLayer(vector, entryPoints, 1, lc)
+	}
+
+	for lc := min(level, g.maxLevel); lc >= 0; lc-- {
+		candidates := g.searchLayer(vector, entryPoints, g.opts.EfConstruction, lc)
+
+		mMax := g.opts.M
+		if lc == 0 {
+			mMax = g.opts.Mmax0
+		}
+
+		neighbors := g.selectNeighbors(vector, candidates, mMax)
+		g.nodes[id].neighbors[lc] = neighbors
+
+		for _, n := range neighbors {
+			g.connect(n, id, lc, mMax)
+		}
+
+		entryPoints = candidates
+	}
+
+	if level > g.maxLevel {
+		g.entry = id
+		g.maxLevel = level
+	}
+
+	return id, nil
+}
+
+// connect adds id as a neighbor of n at level lc, pruning n's neighbor
+// list back down to mMax with the same heuristic used at insert time if
+// it overflows.
+func (g *Graph) connect(n, id, lc, mMax int) {
+	for len(g.nodes[n].neighbors) <= lc {
+		g.nodes[n].neighbors = append(g.nodes[n].neighbors, nil)
+	}
+
+	g.nodes[n].neighbors[lc] = append(g.nodes[n].neighbors[lc], id)
+
+	if len(g.nodes[n].neighbors[lc]) <= mMax {
+		return
+	}
+
+	candidates := make([]candidate, len(g.nodes[n].neighbors[lc]))
+	for i, nb := range g.nodes[n].neighbors[lc] {
+		candidates[i] = candidate{id: nb, dist: g.dist(g.nodes[n].vector, g.nodes[nb].vector)}
+	}
+	g.nodes[n].neighbors[lc] = g.selectNeighbors(g.nodes[n].vector, candidates, mMax)
+}
+
+// selectNeighbors implements the HNSW heuristic: walk candidates from
+// closest to farthest, keeping one only if it is closer to the new
+// point than to every neighbor already selected. This spreads
+// neighbors out instead of clustering them all on one side of the
+// point, which is what plain "closest M" would do.
+func (g *Graph) selectNeighbors(point []float32, candidates []candidate, m int) []int {
+	sorted := append([]candidate(nil), candidates...)
+	sortByDist(sorted)
+
+	selected := make([]int, 0, m)
+	selectedVecs := make([][]float32, 0, m)
+
+	for _, c := range sorted {
+		if len(selected) >= m {
+			break
+		}
+
+		keep := true
+		for _, sv := range selectedVecs {
+			if g.dist(g.nodes[c.id].vector, sv) < c.dist {
+				keep = false
+				break
+			}
+		}
+
+		if keep {
+			selected = append(selected, c.id)
+			selectedVecs = append(selectedVecs, g.nodes[c.id].vector)
+		}
+	}
+
+	return selected
+}
+
+// Search returns the ids of the k nearest neighbors to vector.
+func (g *Graph) Search(vector []float32, k int) []int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if g.entry == -1 {
+		return nil
+	}
+
+	entryPoints := []candidate{{id: g.entry, dist: g.dist(vector, g.nodes[g.entry].vector)}}
+
+	for lc := g.maxLevel; lc > 0; lc-- {
+		entryPoints = g.searchLayer(vector, entryPoints, 1, lc)
+	}
+
+	ef := g.opts.EfSearch
+	if ef < k {
+		ef = k
+	}
+	candidates := g.searchLayer(vector, entryPoints, ef, 0)
+	sortByDist(candidates)
+
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+
+	ids := make([]int, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.id
+	}
+	return ids
+}
+
+// Vector returns the vector stored for id.
+func (g *Graph) Vector(id int) []float32 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.nodes[id].vector
+}
+
+type candidate struct {
+	id   int
+	dist float32
+}
+
+func sortByDist(c []candidate) {
+	// Small lists (ef is usually in the low hundreds at most), so a
+	// simple insertion sort beats pulling in sort.Slice's overhead.
+	for i := 1; i < len(c); i++ {
+		for j := i; j > 0 && c[j].dist < c[j-1].dist; j-- {
+			c[j], c[j-1] = c[j-1], c[j]
+		}
+	}
+}
+
+// searchLayer is Algorithm 2 (SEARCH-LAYER) from the HNSW paper: a
+// best-first search bounded to ef results, seeded from entryPoints.
+func (g *Graph) searchLayer(vector []float32, entryPoints []candidate, ef int, level int) []candidate {
+	visited := make(map[int]bool, ef*2)
+	candidates := &minHeap{}
+	results := &maxHeap{}
+
+	for _, ep := range entryPoints {
+		visited[ep.id] = true
+		heap.Push(candidates, ep)
+		heap.Push(results, ep)
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(candidate)
+
+		if results.Len() >= ef && c.dist > (*results)[0].dist {
+			break
+		}
+
+		if level >= len(g.nodes[c.id].neighbors) {
+			continue
+		}
+
+		for _, n := range g.nodes[c.id].neighbors[level] {
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+
+			d := g.dist(vector, g.nodes[n].vector)
+			if results.Len() < ef || d < (*results)[0].dist {
+				cand := candidate{id: n, dist: d}
+				heap.Push(candidates, cand)
+				heap.Push(results, cand)
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := make([]candidate, results.Len())
+	copy(out, *results)
+	return out
+}
+
+// dist returns a distance where smaller means closer, regardless of
+// the configured metric.
+func (g *Graph) dist(a, b []float32) float32 {
+	switch g.opts.Distance {
+	case L2:
+		return l2(a, b)
+	case InnerProduct:
+		return -dot(a, b)
+	default:
+		return 1 - cosine(a, b)
+	}
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func l2(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
+
+func cosine(a, b []float32) float32 {
+	dotProduct := dot(a, b)
+	var normA, normB float32
+	for i := range a {
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dotProduct / (float32(math.Sqrt(float64(normA))) * float32(math.Sqrt(float64(normB))))
+}
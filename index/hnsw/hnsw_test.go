@@ -0,0 +1,129 @@
+package hnsw_test
+
+import (
+	"math/rand"
+	"pardusdb/index/hnsw"
+	"testing"
+)
+
+func randomVector(dim int, r *rand.Rand) []float32 {
+	v := make([]float32, dim)
+	for i := range v {
+		v[i] = r.Float32()
+	}
+	return v
+}
+
+func flatSearch(vectors [][]float32, query []float32, k int) []int {
+	type scored struct {
+		id   int
+		dist float32
+	}
+	scores := make([]scored, len(vectors))
+	for i, v := range vectors {
+		var sum float32
+		for d := range v {
+			diff := v[d] - query[d]
+			sum += diff * diff
+		}
+		scores[i] = scored{id: i, dist: sum}
+	}
+	for i := 1; i < len(scores); i++ {
+		for j := i; j > 0 && scores[j].dist < scores[j-1].dist; j-- {
+			scores[j], scores[j-1] = scores[j-1], scores[j]
+		}
+	}
+	if k > len(scores) {
+		k = len(scores)
+	}
+	ids := make([]int, k)
+	for i := 0; i < k; i++ {
+		ids[i] = scores[i].id
+	}
+	return ids
+}
+
+func TestSearchFindsNearestNeighbor(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	const dim = 16
+
+	g := hnsw.New(hnsw.Options{Distance: hnsw.L2})
+	vectors := make([][]float32, 200)
+	for i := range vectors {
+		vectors[i] = randomVector(dim, r)
+		if _, err := g.Insert(vectors[i]); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	query := randomVector(dim, r)
+	want := flatSearch(vectors, query, 1)[0]
+	got := g.Search(query, 5)
+
+	found := false
+	for _, id := range got {
+		if id == want {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Errorf("exact nearest neighbor %d not in top-5 hnsw results %v", want, got)
+	}
+}
+
+// BenchmarkHNSWSearch and BenchmarkFlatSearch let you compare recall and
+// latency of the graph index against the naive flat scan it's meant to
+// replace for large tables. BenchmarkHNSWSearch reports its recall@10
+// against flatSearch's exact top 10.
+func BenchmarkHNSWSearch(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	const dim = 64
+	const n = 5000
+
+	g := hnsw.New(hnsw.Options{Distance: hnsw.L2})
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		vectors[i] = randomVector(dim, r)
+		if _, err := g.Insert(vectors[i]); err != nil {
+			b.Fatalf("insert: %v", err)
+		}
+	}
+	query := randomVector(dim, r)
+
+	b.ResetTimer()
+	var got []int
+	for range b.N {
+		got = g.Search(query, 10)
+	}
+	b.StopTimer()
+
+	exact := map[int]bool{}
+	for _, id := range flatSearch(vectors, query, 10) {
+		exact[id] = true
+	}
+	hits := 0
+	for _, id := range got {
+		if exact[id] {
+			hits++
+		}
+	}
+	b.ReportMetric(float64(hits)/10, "recall@10")
+}
+
+func BenchmarkFlatSearch(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	const dim = 64
+	const n = 5000
+
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		vectors[i] = randomVector(dim, r)
+	}
+	query := randomVector(dim, r)
+
+	b.ResetTimer()
+	for range b.N {
+		flatSearch(vectors, query, 10)
+	}
+}
@@ -1,3 +1,13 @@
+//go:build pardusdb_local_inference
+
+// Package inference bridges to a local GGUF/BERT model via cgo. It's
+// gated behind the pardusdb_local_inference build tag because it
+// requires inference.h and its C implementation (embed_text, filereader,
+// closefile, magic_word) to be supplied by the deployment embedding this
+// module - neither ships in this repo, so building without the tag
+// would fail unconditionally for everyone who doesn't need local
+// inference. Build with `-tags pardusdb_local_inference` once those are
+// in place.
 package inference
 
 /*
@@ -12,6 +22,10 @@ import (
 	"unsafe"
 )
 
+// File is a handle to an open model file, usable by other packages without
+// them needing to import "C" themselves.
+type File = C.FILE
+
 func FileReader(filename string) *C.FILE {
 	cFilename := C.CString(filename)
 	defer C.free(unsafe.Pointer(cFilename))
@@ -26,3 +40,19 @@ func GGUFCheck(f *C.FILE) {
 	b := C.magic_word(f)
 	fmt.Println(b)
 }
+
+// Embed runs a single forward pass of the loaded GGUF/BERT model over text
+// and returns its embedding vector. dim must match the model's configured
+// output size, since the C side writes directly into a fixed-size buffer.
+func Embed(f *C.FILE, text string, dim int) ([]float32, error) {
+	cText := C.CString(text)
+	defer C.free(unsafe.Pointer(cText))
+
+	out := make([]float32, dim)
+	n := C.embed_text(f, cText, (*C.float)(unsafe.Pointer(&out[0])), C.int(dim))
+	if int(n) != dim {
+		return nil, fmt.Errorf("inference: expected %d floats, model wrote %d", dim, int(n))
+	}
+
+	return out, nil
+}
@@ -2,127 +2,65 @@ package main
 
 import (
 	"fmt"
-	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
 	"pardusdb/db"
 	"pardusdb/net"
-
-	"github.com/gin-gonic/gin"
+	"pardusdb/net/httpapi"
+	"pardusdb/storage"
 )
 
-type QueryParam struct {
-	Query     string
-	TableName string
-	DBName    string
-}
+// dataRoot holds one subdirectory per database, each a storage.Store.
+const dataRoot = "./pardus_data"
 
-type CreateDBParam struct {
-	Name string
+var storeOpts = storage.Options{
+	FsyncPolicy:  storage.FsyncInterval,
+	SyncInterval: time.Second,
+	CompactEvery: 1000,
 }
 
-type CreateTableParam struct {
-	Name     string
-	Capacity uint32
-	DB       string // db name
-}
+// loadCache opens every database found under dataRoot, replaying each
+// one's WAL onto its last snapshot.
+func loadCache() (*net.Cache, error) {
+	cache := &net.Cache{Room: map[string]*db.Handle{}}
 
-type InsertParam struct {
-	DBName    string
-	TableName string
-	Query     string // val Text
-	Val       db.Val
-}
-
-func main() {
-
-	cache := net.Cache{
-		Room: map[string]*db.PardusDB{},
+	entries, err := os.ReadDir(dataRoot)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return cache, fmt.Errorf("loadCache: %v", err)
 	}
 
-	router := gin.Default()
-
-	router.POST("/query", func(ctx *gin.Context) {
-		var param QueryParam
-		if ctx.ShouldBindQuery(&param) == nil {
-			database, found := cache.Room[param.DBName]
-			if !found {
-				fmt.Println("Database not found")
-				return
-			}
-			table, found := database.Tables[param.TableName]
-			if !found {
-				fmt.Println("Table not found")
-				return
-			}
-			fmt.Println("get table")
-			val, _ := db.Query(param.Query, table)
-			ctx.JSON(http.StatusOK, gin.H{
-				"val": val.Text,
-			})
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
 		}
-	})
-
-	router.POST("/insert", func(ctx *gin.Context) {
-		var param InsertParam
-		if ctx.ShouldBindQuery(&param) == nil {
-			// here it actually should fetch the db at the back and save in RAM
-			// Now it is just a temp solution
-			database, found := cache.Room[param.DBName]
-			if !found {
-				return
-			}
-			// TODO: more robust way to handle db.val
-			db.InsertRow(param.TableName, param.Query, db.Val{Text: param.Query}, database)
-			return
+		handle, err := db.Open(filepath.Join(dataRoot, entry.Name()), storeOpts)
+		if err != nil {
+			return cache, fmt.Errorf("loadCache: open %s: %v", entry.Name(), err)
 		}
-	})
-
-	router.POST("/createdb", func(ctx *gin.Context) {
-		var param CreateDBParam
-		if ctx.ShouldBindQuery(&param) == nil {
-			_, found := cache.Room[param.Name]
-			if found {
-				ctx.JSON(http.StatusConflict, gin.H{
-					"error": "db exists",
-				})
-				return
-			}
-
-			new_db := db.CreateDB(param.Name)
-			cache.Room[param.Name] = &new_db
-
-			ctx.JSON(http.StatusOK, gin.H{
-				"message": "create successfully",
-			})
-			return
-		}
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "bad request",
-		})
-	})
-
-	router.POST("/createtable", func(ctx *gin.Context) {
-		var param CreateTableParam
-		if ctx.ShouldBindQuery(&param) == nil {
-			database, found := cache.Room[param.DB]
+		cache.Set(entry.Name(), handle)
+	}
 
-			if !found {
-				ctx.JSON(http.StatusBadRequest, gin.H{
-					"error": "database not found",
-				})
-				return
-			}
+	return cache, nil
+}
 
-			db.CreateTable(param.Name, param.Capacity, database)
+func main() {
+	cache, err := loadCache()
+	if err != nil {
+		panic(err)
+	}
 
-			ctx.JSON(http.StatusOK, gin.H{
-				"message": "create table successfully",
-			})
-			return
-		}
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "bad request",
-		})
+	server := httpapi.New(cache, httpapi.Config{
+		AuthToken: os.Getenv("PARDUSDB_AUTH_TOKEN"),
+		DataRoot:  dataRoot,
+		StoreOpts: storeOpts,
 	})
 
-	router.Run()
+	if err := server.Run(); err != nil {
+		panic(err)
+	}
 }
@@ -0,0 +1,49 @@
+package net
+
+import (
+	"sync"
+
+	"pardusdb/db"
+)
+
+// Cache holds every open database, keyed by name, for the lifetime of
+// the server process. Its own map is guarded separately from each
+// db.Handle's internal locking (see db.PardusDB's mu), since /createdb
+// and the /metrics endpoint can touch it concurrently with request
+// handlers looking databases up.
+type Cache struct {
+	mu   sync.RWMutex
+	Room map[string]*db.Handle
+}
+
+// Get returns the database opened under name, if any.
+func (c *Cache) Get(name string) (*db.Handle, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	h, found := c.Room[name]
+	return h, found
+}
+
+// Set registers a database under name, overwriting any previous entry.
+func (c *Cache) Set(name string, h *db.Handle) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Room[name] = h
+}
+
+// Has reports whether a database is registered under name.
+func (c *Cache) Has(name string) bool {
+	_, found := c.Get(name)
+	return found
+}
+
+// Names returns the name of every currently open database.
+func (c *Cache) Names() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, 0, len(c.Room))
+	for name := range c.Room {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,109 @@
+package httpapi
+
+import (
+	"time"
+
+	"pardusdb/db"
+	"pardusdb/embed"
+)
+
+// ErrorResponse is the JSON body returned for every non-2xx response,
+// so clients never have to guess whether an error came back as plain
+// text or something else.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// CreateDBRequest is /createdb's body.
+type CreateDBRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// CreateTableRequest is /createtable's body. Backend picks which
+// embed.Embedder implementation the table uses (see embed.Backend);
+// it defaults to "ollama" to keep existing callers working. BaseURL,
+// APIKey, TimeoutMS and MaxRetries feed the backend's embed.Config;
+// Addr is the gRPC dial target for "localai" or the model path for
+// "local".
+type CreateTableRequest struct {
+	DB       string `json:"db" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Capacity uint32 `json:"capacity" binding:"required"`
+	Model    string `json:"model" binding:"required"`
+	Dim      int    `json:"dim" binding:"required"`
+
+	Backend string `json:"backend,omitempty"`
+	Addr    string `json:"addr,omitempty"`
+
+	BaseURL    string `json:"base_url,omitempty"`
+	APIKey     string `json:"api_key,omitempty"`
+	TimeoutMS  int    `json:"timeout_ms,omitempty"`
+	MaxRetries int    `json:"max_retries,omitempty"`
+}
+
+// embedderSpec builds the embed.Spec req describes.
+func (req CreateTableRequest) embedderSpec() embed.Spec {
+	backend := embed.Backend(req.Backend)
+	if backend == "" {
+		backend = embed.BackendOllama
+	}
+	return embed.Spec{
+		Backend: backend,
+		Model:   req.Model,
+		Dim:     req.Dim,
+		Addr:    req.Addr,
+		Config: embed.Config{
+			BaseURL:    req.BaseURL,
+			APIKey:     req.APIKey,
+			Timeout:    time.Duration(req.TimeoutMS) * time.Millisecond,
+			MaxRetries: req.MaxRetries,
+		},
+	}
+}
+
+// InsertRequest is /insert's body.
+type InsertRequest struct {
+	DB       string         `json:"db" binding:"required"`
+	Table    string         `json:"table" binding:"required"`
+	Text     string         `json:"text" binding:"required"`
+	MetaData map[string]any `json:"metadata,omitempty"`
+}
+
+// BatchInsertRequest is /batch_insert's body. Texts is the JSON array
+// this route exists to accept; MetaData, if given, pairs up with Texts
+// by index.
+type BatchInsertRequest struct {
+	DB       string           `json:"db" binding:"required"`
+	Table    string           `json:"table" binding:"required"`
+	Texts    []string         `json:"texts" binding:"required"`
+	MetaData []map[string]any `json:"metadata,omitempty"`
+}
+
+// QueryRequest is /query's body. TopK and Nprobe override the table's
+// configured defaults and Threshold overrides db.THRESHOLD, for this
+// query only; the zero value of each keeps the default.
+type QueryRequest struct {
+	DB        string  `json:"db" binding:"required"`
+	Table     string  `json:"table" binding:"required"`
+	Query     string  `json:"query" binding:"required"`
+	TopK      uint32  `json:"top_k"`
+	Nprobe    uint32  `json:"nprobe"`
+	Threshold float32 `json:"threshold"`
+}
+
+// QueryResponse is /query's body on success.
+type QueryResponse struct {
+	Text           string         `json:"text"`
+	MetaData       map[string]any `json:"metadata,omitempty"`
+	Similarity     float32        `json:"similarity"`
+	AboveThreshold bool           `json:"above_threshold"`
+}
+
+func queryResponse(result db.QueryResult, threshold float32) QueryResponse {
+	return QueryResponse{
+		Text:           result.Val.Text,
+		MetaData:       result.Val.MetaData,
+		Similarity:     result.Similarity,
+		AboveThreshold: result.Similarity >= threshold,
+	}
+}
@@ -0,0 +1,178 @@
+package httpapi
+
+import (
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"pardusdb/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func (s *Server) registerRoutes() {
+	s.engine.GET("/healthz", s.handleHealthz)
+	s.engine.GET("/metrics", gin.WrapH(promhttp.HandlerFor(s.metrics.registry, promhttp.HandlerOpts{})))
+
+	api := s.engine.Group("/")
+	api.Use(bearerAuth(s.cfg.AuthToken))
+
+	api.POST("/query", s.handleQuery)
+	api.POST("/insert", s.handleInsert)
+	api.POST("/batch_insert", s.handleBatchInsert)
+	api.POST("/createdb", s.handleCreateDB)
+	api.POST("/createtable", s.handleCreateTable)
+}
+
+func (s *Server) handleHealthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (s *Server) handleQuery(ctx *gin.Context) {
+	var req QueryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	handle, found := s.cache.Get(req.DB)
+	if !found {
+		writeError(ctx, http.StatusNotFound, "database not found")
+		return
+	}
+
+	table, found := handle.Table(req.Table)
+	if !found {
+		writeError(ctx, http.StatusNotFound, "table not found")
+		return
+	}
+
+	threshold := req.Threshold
+	if threshold == 0 {
+		threshold = db.THRESHOLD
+	}
+
+	start := time.Now()
+	result, err := db.QueryWithOptions(req.Query, table, db.QueryOptions{TopK: req.TopK, Nprobe: req.Nprobe})
+	s.metrics.embeddingDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		writeError(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := queryResponse(result, threshold)
+	s.metrics.recordQuery(resp.AboveThreshold)
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) handleInsert(ctx *gin.Context) {
+	var req InsertRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	handle, found := s.cache.Get(req.DB)
+	if !found {
+		writeError(ctx, http.StatusNotFound, "database not found")
+		return
+	}
+
+	start := time.Now()
+	err := handle.InsertRow(req.Table, db.Val{Text: req.Text, MetaData: req.MetaData})
+	s.metrics.embeddingDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		writeError(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "insert successfully"})
+}
+
+func (s *Server) handleBatchInsert(ctx *gin.Context) {
+	var req BatchInsertRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.MetaData) != 0 && len(req.MetaData) != len(req.Texts) {
+		writeError(ctx, http.StatusBadRequest, "metadata, if given, must have one entry per text")
+		return
+	}
+
+	handle, found := s.cache.Get(req.DB)
+	if !found {
+		writeError(ctx, http.StatusNotFound, "database not found")
+		return
+	}
+
+	vals := make([]db.Val, len(req.Texts))
+	for i, text := range req.Texts {
+		vals[i] = db.Val{Text: text}
+		if len(req.MetaData) != 0 {
+			vals[i].MetaData = req.MetaData[i]
+		}
+	}
+
+	start := time.Now()
+	err := handle.Batch(req.Table, vals)
+	s.metrics.embeddingDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		writeError(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "batch insert successfully"})
+}
+
+func (s *Server) handleCreateDB(ctx *gin.Context) {
+	var req CreateDBRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if s.cache.Has(req.Name) {
+		writeError(ctx, http.StatusConflict, "db exists")
+		return
+	}
+
+	handle, err := db.Open(filepath.Join(s.cfg.DataRoot, req.Name), s.cfg.StoreOpts)
+	if err != nil {
+		writeError(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.cache.Set(req.Name, handle)
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "create successfully"})
+}
+
+func (s *Server) handleCreateTable(ctx *gin.Context) {
+	var req CreateTableRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	handle, found := s.cache.Get(req.DB)
+	if !found {
+		writeError(ctx, http.StatusBadRequest, "database not found")
+		return
+	}
+
+	spec := req.embedderSpec()
+	embedder, err := spec.Build()
+	if err != nil {
+		writeError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, err := handle.CreateTable(req.Name, req.Capacity, embedder, db.TableOptions{EmbedderSpec: spec}); err != nil {
+		writeError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "create table successfully"})
+}
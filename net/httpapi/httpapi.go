@@ -0,0 +1,69 @@
+// Package httpapi is PardusDB's REST layer: JSON request/response DTOs,
+// structured error responses, a bearer-token auth middleware, request
+// logging, and a /healthz + Prometheus /metrics pair, built on top of
+// the same pardusdb/net.Cache and pardusdb/db.Handle types the rest of
+// the server uses.
+package httpapi
+
+import (
+	"net/http"
+
+	"pardusdb/net"
+	"pardusdb/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config configures the gin engine httpapi builds.
+type Config struct {
+	// AuthToken, if non-empty, is the bearer token every route other
+	// than /healthz and /metrics requires. Empty disables auth.
+	AuthToken string
+
+	// DataRoot is where /createdb persists a new database's directory,
+	// one subdirectory per database (mirroring how the cache itself was
+	// populated at startup).
+	DataRoot string
+	// StoreOpts is the storage.Options every /createdb call opens its
+	// new database with.
+	StoreOpts storage.Options
+}
+
+// Server wires a net.Cache into a gin.Engine with auth, logging, and
+// metrics middleware plus PardusDB's REST routes.
+type Server struct {
+	cache   *net.Cache
+	cfg     Config
+	metrics *metrics
+	engine  *gin.Engine
+}
+
+// New builds a Server ready to Run. cache is shared with the caller, so
+// databases opened before the server starts (or by something else with
+// a reference to the same cache) are visible to it.
+func New(cache *net.Cache, cfg Config) *Server {
+	s := &Server{
+		cache:   cache,
+		cfg:     cfg,
+		metrics: newMetrics(cache),
+		engine:  gin.New(),
+	}
+
+	s.engine.Use(gin.Recovery(), requestLogger())
+	s.registerRoutes()
+
+	return s
+}
+
+// Run starts the HTTP server, blocking until it exits. addr defaults to
+// gin's own default (":8080") when omitted.
+func (s *Server) Run(addr ...string) error {
+	return s.engine.Run(addr...)
+}
+
+// Handler returns the http.Handler serving PardusDB's REST routes, for
+// embedding in a caller-managed http.Server (e.g. httptest.NewServer in
+// tests) instead of Run.
+func (s *Server) Handler() http.Handler {
+	return s.engine
+}
@@ -0,0 +1,177 @@
+package httpapi_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pardusdb/db"
+	"pardusdb/net"
+	"pardusdb/net/httpapi"
+	"pardusdb/storage"
+)
+
+// hashEmbedder deterministically maps each distinct text to its own
+// fixed vector, the same way db_test.hashEmbedder does, so these tests
+// don't depend on a running Ollama server.
+type hashEmbedder struct{ dim int }
+
+func (h *hashEmbedder) Name() string { return "hash" }
+func (h *hashEmbedder) Dim() int     { return h.dim }
+func (h *hashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		var seed int64
+		for _, c := range text {
+			seed = seed*131 + int64(c)
+		}
+		r := rand.New(rand.NewSource(seed))
+		v := make([]float32, h.dim)
+		for d := range v {
+			v[d] = r.Float32()
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// newTestServer opens a "db" database with one empty "t" table backed by
+// hashEmbedder, and wires it into an httpapi.Server with the given auth
+// token (empty disables auth).
+func newTestServer(t *testing.T, authToken string) *httptest.Server {
+	t.Helper()
+
+	h, err := db.Open(t.TempDir(), storage.Options{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { h.Close() })
+
+	if _, err := h.CreateTable("t", 16, &hashEmbedder{dim: 8}, db.TableOptions{}); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	cache := &net.Cache{Room: map[string]*db.Handle{"db": h}}
+	srv := httpapi.New(cache, httpapi.Config{AuthToken: authToken})
+
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func postJSON(t *testing.T, ts *httptest.Server, path, token string, body any) *http.Response {
+	t.Helper()
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+path, bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("do request: %v", err)
+	}
+	return resp
+}
+
+func TestInsertQueryRoundTrip(t *testing.T) {
+	ts := newTestServer(t, "")
+
+	resp := postJSON(t, ts, "/insert", "", map[string]any{
+		"db": "db", "table": "t", "text": "hello world",
+		"metadata": map[string]any{"tags": []any{"a", "b"}},
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("insert status = %d, want 200", resp.StatusCode)
+	}
+	resp.Body.Close()
+
+	resp = postJSON(t, ts, "/query", "", map[string]any{"db": "db", "table": "t", "query": "hello world"})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("query status = %d, want 200", resp.StatusCode)
+	}
+
+	var out httpapi.QueryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if out.Text != "hello world" {
+		t.Fatalf("Text = %q, want %q", out.Text, "hello world")
+	}
+	if !out.AboveThreshold {
+		t.Fatalf("AboveThreshold = false, want true for querying the exact inserted text")
+	}
+}
+
+func TestBatchInsertMetaDataLengthMismatch(t *testing.T) {
+	ts := newTestServer(t, "")
+
+	resp := postJSON(t, ts, "/batch_insert", "", map[string]any{
+		"db": "db", "table": "t",
+		"texts":    []string{"a", "b"},
+		"metadata": []map[string]any{{"k": "v"}},
+	})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 for mismatched metadata length", resp.StatusCode)
+	}
+}
+
+func TestQueryUnknownDatabase(t *testing.T) {
+	ts := newTestServer(t, "")
+
+	resp := postJSON(t, ts, "/query", "", map[string]any{"db": "nope", "table": "t", "query": "hi"})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 for unknown database", resp.StatusCode)
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	ts := newTestServer(t, "secret")
+
+	resp := postJSON(t, ts, "/query", "", map[string]any{"db": "db", "table": "t", "query": "hi"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401 with no bearer token", resp.StatusCode)
+	}
+
+	resp = postJSON(t, ts, "/query", "wrong", map[string]any{"db": "db", "table": "t", "query": "hi"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401 with a wrong bearer token", resp.StatusCode)
+	}
+
+	resp = postJSON(t, ts, "/query", "secret", map[string]any{"db": "db", "table": "t", "query": "hi"})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200 with the correct bearer token", resp.StatusCode)
+	}
+}
+
+func TestHealthzSkipsAuth(t *testing.T) {
+	ts := newTestServer(t, "secret")
+
+	resp, err := http.Get(ts.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("get /healthz: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200 for /healthz without a bearer token", resp.StatusCode)
+	}
+}
@@ -0,0 +1,101 @@
+package httpapi
+
+import (
+	"sync/atomic"
+
+	"pardusdb/net"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics backs /metrics: embedding latency, the db.THRESHOLD cache-hit
+// ratio, and per-table vector counts. The last is collected live from
+// cache at scrape time rather than tracked incrementally, since
+// db.Table.Size() is already safe to call concurrently.
+type metrics struct {
+	registry *prometheus.Registry
+
+	embeddingDuration prometheus.Histogram
+
+	queriesTotal          atomic.Uint64
+	queriesAboveThreshold atomic.Uint64
+}
+
+func newMetrics(cache *net.Cache) *metrics {
+	m := &metrics{
+		embeddingDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name: "pardusdb_embedding_duration_seconds",
+			Help: "Latency of requests that embed text (query/insert/batch_insert), " +
+				"which embedding dominates for anything but very large batches.",
+			Buckets: prometheus.DefBuckets,
+		}),
+	}
+
+	m.registry = prometheus.NewRegistry()
+	m.registry.MustRegister(m.embeddingDuration)
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "pardusdb_query_cache_hit_ratio",
+		Help: "Fraction of /query calls whose best match scored at or above the effective threshold.",
+	}, m.hitRatio))
+	m.registry.MustRegister(newTableSizeCollector(cache))
+
+	return m
+}
+
+// recordQuery tallies a /query call's outcome for the cache-hit-ratio
+// gauge. "Hit" here means the match cleared the request's effective
+// threshold, matching db.THRESHOLD's historical meaning.
+func (m *metrics) recordQuery(aboveThreshold bool) {
+	m.queriesTotal.Add(1)
+	if aboveThreshold {
+		m.queriesAboveThreshold.Add(1)
+	}
+}
+
+func (m *metrics) hitRatio() float64 {
+	total := m.queriesTotal.Load()
+	if total == 0 {
+		return 0
+	}
+	return float64(m.queriesAboveThreshold.Load()) / float64(total)
+}
+
+// tableSizeCollector reports pardusdb_table_vectors for every table in
+// every open database, read fresh from cache on each scrape.
+type tableSizeCollector struct {
+	cache *net.Cache
+	desc  *prometheus.Desc
+}
+
+func newTableSizeCollector(cache *net.Cache) *tableSizeCollector {
+	return &tableSizeCollector{
+		cache: cache,
+		desc: prometheus.NewDesc(
+			"pardusdb_table_vectors",
+			"Number of vectors currently indexed in a table.",
+			[]string{"db", "table"}, nil,
+		),
+	}
+}
+
+func (c *tableSizeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *tableSizeCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, dbName := range c.cache.Names() {
+		handle, found := c.cache.Get(dbName)
+		if !found {
+			continue
+		}
+		for _, tableName := range handle.TableNames() {
+			table, found := handle.Table(tableName)
+			if !found {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				c.desc, prometheus.GaugeValue, float64(table.Size()), dbName, tableName,
+			)
+		}
+	}
+}
@@ -0,0 +1,419 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"time"
+)
+
+const snapshotMagic = "PDSB"
+const snapshotVersion = uint32(2)
+
+// SnapshotObject mirrors db.Object without importing the db package, so
+// storage stays a leaf dependency.
+type SnapshotObject struct {
+	Text     string
+	MetaData map[string]any
+	Time     time.Time
+	Vector   []float32
+}
+
+// SnapshotLayer mirrors db.Layer.
+type SnapshotLayer struct {
+	Centroid []float32
+	Objects  []SnapshotObject
+}
+
+// SnapshotTable mirrors db.Table.
+type SnapshotTable struct {
+	Name     string
+	Capacity uint32
+	Embedder EmbedderSpec
+	Layers   []SnapshotLayer
+}
+
+// WriteSnapshot serializes tables to path as length-prefixed binary data.
+// Vectors and centroids are written as raw float32 blobs rather than
+// JSON/gob so a table with millions of vectors doesn't balloon on disk.
+//
+// walSeq is the number of WAL records already reflected in tables. The
+// store stamps it in at compaction time so that replay after a crash
+// can tell which leading records of a not-yet-truncated WAL it has
+// already applied, instead of relying on the truncate having happened.
+func WriteSnapshot(path string, tables map[string]SnapshotTable, walSeq uint64) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("storage: create snapshot: %v", err)
+	}
+
+	w := bufio.NewWriter(f)
+	if err := writeSnapshot(w, tables, walSeq); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("storage: flush snapshot: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("storage: fsync snapshot: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// Atomic rename so a crash mid-write never leaves a half-written
+	// snapshot where the previous good one used to be.
+	return os.Rename(tmp, path)
+}
+
+func writeSnapshot(w *bufio.Writer, tables map[string]SnapshotTable, walSeq uint64) error {
+	if _, err := w.WriteString(snapshotMagic); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, snapshotVersion); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, walSeq); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(tables))); err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		if err := writeString(w, table.Name); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, table.Capacity); err != nil {
+			return err
+		}
+		if err := writeEmbedderSpec(w, table.Embedder); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, uint32(len(table.Layers))); err != nil {
+			return err
+		}
+		for _, layer := range table.Layers {
+			if err := writeFloats(w, layer.Centroid); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.LittleEndian, uint32(len(layer.Objects))); err != nil {
+				return err
+			}
+			for _, obj := range layer.Objects {
+				if err := writeString(w, obj.Text); err != nil {
+					return err
+				}
+				if err := binary.Write(w, binary.LittleEndian, obj.Time.UnixNano()); err != nil {
+					return err
+				}
+				if err := writeFloats(w, obj.Vector); err != nil {
+					return err
+				}
+				// MetaData is arbitrary map[string]any; gob is the
+				// simplest thing that round-trips it faithfully.
+				metaBytes, err := gobEncode(obj.MetaData)
+				if err != nil {
+					return err
+				}
+				if err := writeBytes(w, metaBytes); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReadSnapshot loads the tables previously written by WriteSnapshot,
+// along with the WAL sequence number stamped in at the time it was
+// written (see WriteSnapshot).
+func ReadSnapshot(path string) (map[string]SnapshotTable, uint64, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return map[string]SnapshotTable{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("storage: open snapshot: %v", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := readFull(r, magic); err != nil {
+		return nil, 0, fmt.Errorf("storage: read snapshot magic: %v", err)
+	}
+	if string(magic) != snapshotMagic {
+		return nil, 0, fmt.Errorf("storage: bad snapshot magic %q", magic)
+	}
+
+	var version uint32
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, 0, fmt.Errorf("storage: read snapshot version: %v", err)
+	}
+	if version != snapshotVersion {
+		return nil, 0, fmt.Errorf("storage: unsupported snapshot version %d", version)
+	}
+
+	var walSeq uint64
+	if err := binary.Read(r, binary.LittleEndian, &walSeq); err != nil {
+		return nil, 0, fmt.Errorf("storage: read snapshot wal seq: %v", err)
+	}
+
+	var tableCount uint32
+	if err := binary.Read(r, binary.LittleEndian, &tableCount); err != nil {
+		return nil, 0, err
+	}
+
+	tables := make(map[string]SnapshotTable, tableCount)
+	for range tableCount {
+		name, err := readString(r)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		var capacity uint32
+		if err := binary.Read(r, binary.LittleEndian, &capacity); err != nil {
+			return nil, 0, err
+		}
+
+		embedder, err := readEmbedderSpec(r)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		var layerCount uint32
+		if err := binary.Read(r, binary.LittleEndian, &layerCount); err != nil {
+			return nil, 0, err
+		}
+
+		layers := make([]SnapshotLayer, 0, layerCount)
+		for range layerCount {
+			centroid, err := readFloats(r)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			var objCount uint32
+			if err := binary.Read(r, binary.LittleEndian, &objCount); err != nil {
+				return nil, 0, err
+			}
+
+			objects := make([]SnapshotObject, 0, objCount)
+			for range objCount {
+				text, err := readString(r)
+				if err != nil {
+					return nil, 0, err
+				}
+
+				var nanos int64
+				if err := binary.Read(r, binary.LittleEndian, &nanos); err != nil {
+					return nil, 0, err
+				}
+
+				vector, err := readFloats(r)
+				if err != nil {
+					return nil, 0, err
+				}
+
+				metaBytes, err := readBytes(r)
+				if err != nil {
+					return nil, 0, err
+				}
+				meta, err := gobDecodeMeta(metaBytes)
+				if err != nil {
+					return nil, 0, err
+				}
+
+				objects = append(objects, SnapshotObject{
+					Text:     text,
+					MetaData: meta,
+					Time:     time.Unix(0, nanos),
+					Vector:   vector,
+				})
+			}
+
+			layers = append(layers, SnapshotLayer{Centroid: centroid, Objects: objects})
+		}
+
+		tables[name] = SnapshotTable{Name: name, Capacity: capacity, Embedder: embedder, Layers: layers}
+	}
+
+	return tables, walSeq, nil
+}
+
+func writeEmbedderSpec(w *bufio.Writer, spec EmbedderSpec) error {
+	if err := writeString(w, spec.Backend); err != nil {
+		return err
+	}
+	if err := writeString(w, spec.Model); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, int64(spec.Dim)); err != nil {
+		return err
+	}
+	if err := writeString(w, spec.Addr); err != nil {
+		return err
+	}
+	if err := writeString(w, spec.BaseURL); err != nil {
+		return err
+	}
+	if err := writeString(w, spec.APIKey); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, int64(spec.Timeout)); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, int64(spec.MaxRetries))
+}
+
+func readEmbedderSpec(r *bufio.Reader) (EmbedderSpec, error) {
+	var spec EmbedderSpec
+
+	var err error
+	if spec.Backend, err = readString(r); err != nil {
+		return spec, err
+	}
+	if spec.Model, err = readString(r); err != nil {
+		return spec, err
+	}
+	var dim int64
+	if err := binary.Read(r, binary.LittleEndian, &dim); err != nil {
+		return spec, err
+	}
+	spec.Dim = int(dim)
+	if spec.Addr, err = readString(r); err != nil {
+		return spec, err
+	}
+	if spec.BaseURL, err = readString(r); err != nil {
+		return spec, err
+	}
+	if spec.APIKey, err = readString(r); err != nil {
+		return spec, err
+	}
+	var timeout int64
+	if err := binary.Read(r, binary.LittleEndian, &timeout); err != nil {
+		return spec, err
+	}
+	spec.Timeout = time.Duration(timeout)
+	var maxRetries int64
+	if err := binary.Read(r, binary.LittleEndian, &maxRetries); err != nil {
+		return spec, err
+	}
+	spec.MaxRetries = int(maxRetries)
+
+	return spec, nil
+}
+
+func writeString(w *bufio.Writer, s string) error {
+	return writeBytes(w, []byte(s))
+}
+
+func readString(r *bufio.Reader) (string, error) {
+	b, err := readBytes(r)
+	return string(b), err
+}
+
+func writeBytes(w *bufio.Writer, b []byte) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(b))); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+func readBytes(r *bufio.Reader) ([]byte, error) {
+	var n uint32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	if _, err := readFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func writeFloats(w *bufio.Writer, floats []float32) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(floats))); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, floats)
+}
+
+func readFloats(r *bufio.Reader) ([]float32, error) {
+	var n uint32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, err
+	}
+	floats := make([]float32, n)
+	if err := binary.Read(r, binary.LittleEndian, floats); err != nil {
+		return nil, err
+	}
+	return floats, nil
+}
+
+func readFull(r *bufio.Reader, buf []byte) (int, error) {
+	total := 0
+	for total < len(buf) {
+		n, err := r.Read(buf[total:])
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func gobEncode(meta map[string]any) ([]byte, error) {
+	var buf fixedBuffer
+	if err := gob.NewEncoder(&buf).Encode(meta); err != nil {
+		return nil, fmt.Errorf("storage: encode metadata: %v", err)
+	}
+	return buf.data, nil
+}
+
+func gobDecodeMeta(b []byte) (map[string]any, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var meta map[string]any
+	buf := fixedBuffer{data: b}
+	if err := gob.NewDecoder(&buf).Decode(&meta); err != nil {
+		return nil, fmt.Errorf("storage: decode metadata: %v", err)
+	}
+	return meta, nil
+}
+
+// fixedBuffer is a tiny io.Writer/io.Reader over a byte slice, enough to
+// let gob encode/decode without pulling in bytes.Buffer semantics we
+// don't need here.
+type fixedBuffer struct {
+	data []byte
+	pos  int
+}
+
+func (b *fixedBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	return len(p), nil
+}
+
+func (b *fixedBuffer) Read(p []byte) (int, error) {
+	n := copy(p, b.data[b.pos:])
+	b.pos += n
+	if n == 0 {
+		return 0, fmt.Errorf("storage: eof")
+	}
+	return n, nil
+}
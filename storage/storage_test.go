@@ -0,0 +1,144 @@
+package storage_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pardusdb/storage"
+)
+
+// nestedMetaData is shaped the way arbitrary JSON metadata comes out of
+// encoding/json's map[string]any decoding: scalars plus the two
+// composite container types ([]interface{}, map[string]interface{}).
+// Both WAL.Append and the snapshot metadata codec encode MetaData with
+// gob, which refuses to decode a concrete type crossing an interface{}
+// boundary unless it was registered with gob.Register up front.
+func nestedMetaData() map[string]any {
+	return map[string]any{
+		"tags":   []interface{}{"a", "b"},
+		"nested": map[string]interface{}{"x": 1.0, "y": true},
+		"scalar": "hello",
+	}
+}
+
+// TestWALAppendReadAllNestedMetaData is the regression test for the
+// "gob: type not registered for interface" failure: without
+// gob.Register([]interface{}{}) and gob.Register(map[string]interface{}{})
+// in storage/wal.go's init, this fails the moment MetaData nests a slice
+// or map.
+func TestWALAppendReadAllNestedMetaData(t *testing.T) {
+	dir := t.TempDir()
+	walPath := filepath.Join(dir, "wal.log")
+
+	wal, err := storage.OpenWAL(walPath, storage.FsyncNone, 0)
+	if err != nil {
+		t.Fatalf("open wal: %v", err)
+	}
+
+	rec := storage.Record{
+		Op:       storage.OpInsert,
+		Table:    "t",
+		Text:     "hello world",
+		MetaData: nestedMetaData(),
+		Time:     time.Now(),
+		Vector:   []float32{1, 2, 3},
+	}
+	if err := wal.Append(rec); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := wal.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	records, err := storage.ReadAll(walPath)
+	if err != nil {
+		t.Fatalf("read all: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+
+	got := records[0].MetaData
+	tags, ok := got["tags"].([]interface{})
+	if !ok || len(tags) != 2 || tags[0] != "a" {
+		t.Fatalf("tags = %#v, want [a b]", got["tags"])
+	}
+	nested, ok := got["nested"].(map[string]interface{})
+	if !ok || nested["x"] != 1.0 || nested["y"] != true {
+		t.Fatalf("nested = %#v, want map[x:1 y:true]", got["nested"])
+	}
+}
+
+// TestSnapshotRoundTripNestedMetaData covers the same nested-MetaData
+// shape through WriteSnapshot/ReadSnapshot's gobEncode/gobDecodeMeta path.
+func TestSnapshotRoundTripNestedMetaData(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snapshot.bin")
+
+	tables := map[string]storage.SnapshotTable{
+		"t": {
+			Name:     "t",
+			Capacity: 10,
+			Layers: []storage.SnapshotLayer{
+				{
+					Centroid: []float32{0, 0},
+					Objects: []storage.SnapshotObject{
+						{Text: "hi", MetaData: nestedMetaData(), Time: time.Now(), Vector: []float32{1, 2}},
+					},
+				},
+			},
+		},
+	}
+
+	if err := storage.WriteSnapshot(path, tables, 5); err != nil {
+		t.Fatalf("write snapshot: %v", err)
+	}
+
+	got, walSeq, err := storage.ReadSnapshot(path)
+	if err != nil {
+		t.Fatalf("read snapshot: %v", err)
+	}
+	if walSeq != 5 {
+		t.Fatalf("walSeq = %d, want 5", walSeq)
+	}
+
+	obj := got["t"].Layers[0].Objects[0]
+	tags, ok := obj.MetaData["tags"].([]interface{})
+	if !ok || len(tags) != 2 {
+		t.Fatalf("tags = %#v, want a 2-element slice", obj.MetaData["tags"])
+	}
+}
+
+// TestCompactLockedSkipsWhenSnapshotFuncRefuses checks the other half of
+// the HNSW-compaction-safety fix: when SnapshotFunc reports ok=false,
+// Compact leaves the WAL untouched instead of truncating data a partial
+// snapshot can't capture.
+func TestCompactLockedSkipsWhenSnapshotFuncRefuses(t *testing.T) {
+	dir := t.TempDir()
+	store, _, _, err := storage.Open(dir, storage.Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer store.Close()
+
+	store.SetSnapshotFunc(func() (map[string]storage.SnapshotTable, bool) {
+		return nil, false
+	})
+
+	if err := store.Append(storage.Record{Op: storage.OpInsert, Table: "t", Text: "x"}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := store.Compact(); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+
+	walPath := filepath.Join(dir, "wal.log")
+	records, err := storage.ReadAll(walPath)
+	if err != nil {
+		t.Fatalf("read all: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records after a refused compaction, want the original 1 untouched", len(records))
+	}
+}
@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	walFileName      = "wal.log"
+	snapshotFileName = "snapshot.bin"
+)
+
+// SnapshotFunc returns the current in-memory state to persist, plus
+// whether that state is fully representable in a snapshot right now.
+// ok == false means "don't compact yet": compactLocked leaves the WAL
+// untouched instead of truncating data that a partial snapshot
+// couldn't capture (see pardusdb/db.Handle.snapshot, whose IndexHNSW
+// tables can't be serialized this way yet). The caller (pardusdb/db)
+// owns the real tables; storage only knows how to write whatever shape
+// it's handed.
+type SnapshotFunc func() (tables map[string]SnapshotTable, ok bool)
+
+// Store ties a WAL and its snapshot together on disk and owns the
+// background compaction goroutine that keeps the WAL from growing
+// forever.
+type Store struct {
+	dir string
+
+	mu  sync.Mutex
+	wal *WAL
+
+	compactEvery           int // compact after this many WAL appends
+	appendsSinceCompaction int
+
+	snapshotFn SnapshotFunc
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// Options configures a Store.
+type Options struct {
+	FsyncPolicy  FsyncPolicy
+	SyncInterval time.Duration // used when FsyncPolicy == FsyncInterval
+	CompactEvery int           // WAL entries between compactions; 0 disables background compaction
+}
+
+// Open opens (creating if needed) a storage directory at dir, replaying
+// any existing WAL on top of the last snapshot.
+//
+// Replay returns the snapshot tables plus every WAL record written
+// after that snapshot was taken; the caller applies both, in order, to
+// rebuild its own in-memory structures (see db.Open).
+func Open(dir string, opts Options) (*Store, map[string]SnapshotTable, []Record, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, nil, nil, fmt.Errorf("storage: mkdir %s: %v", dir, err)
+	}
+
+	snapshotPath := filepath.Join(dir, snapshotFileName)
+	tables, walSeq, err := ReadSnapshot(snapshotPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	walPath := filepath.Join(dir, walFileName)
+	records, err := ReadAll(walPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// walSeq is how many leading WAL records the snapshot already
+	// reflects. Normally it's 0, since compactLocked restamps the
+	// snapshot once the WAL truncate has gone through, but if a crash
+	// landed between the snapshot rename and the truncate, the WAL can
+	// still hold those same records on disk; skip them here instead of
+	// replaying duplicates on top of a snapshot that already has them.
+	if walSeq > uint64(len(records)) {
+		walSeq = uint64(len(records))
+	}
+	records = records[walSeq:]
+
+	wal, err := OpenWAL(walPath, opts.FsyncPolicy, opts.SyncInterval)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	s := &Store{
+		dir:          dir,
+		wal:          wal,
+		compactEvery: opts.CompactEvery,
+		snapshotFn:   nil,
+		stopCh:       make(chan struct{}),
+	}
+
+	return s, tables, records, nil
+}
+
+// SetSnapshotFunc registers the callback used to build a snapshot during
+// compaction. It must be set before Append is called if CompactEvery > 0.
+func (s *Store) SetSnapshotFunc(fn SnapshotFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshotFn = fn
+}
+
+// Append writes rec to the WAL and triggers a compaction once
+// compactEvery appends have accumulated.
+//
+// The WAL write happens under s.mu, same as compaction, so compactLocked
+// never races a concurrent Append for the count of records currently on
+// disk (see compactLocked).
+func (s *Store) Append(rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.wal.Append(rec); err != nil {
+		return err
+	}
+
+	if s.compactEvery <= 0 || s.snapshotFn == nil {
+		return nil
+	}
+
+	s.appendsSinceCompaction++
+	if s.appendsSinceCompaction < s.compactEvery {
+		return nil
+	}
+
+	if err := s.compactLocked(); err != nil {
+		return err
+	}
+	s.appendsSinceCompaction = 0
+	return nil
+}
+
+// Compact snapshots the current state and truncates the WAL. Safe to
+// call directly (e.g. on a timer) in addition to the automatic trigger
+// in Append.
+func (s *Store) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compactLocked()
+}
+
+func (s *Store) compactLocked() error {
+	if s.snapshotFn == nil {
+		return nil
+	}
+
+	tables, ok := s.snapshotFn()
+	if !ok {
+		// The caller can't fully represent its current state in a
+		// snapshot yet; truncating the WAL now would permanently lose
+		// whatever part of it a snapshot can't capture. Leave
+		// everything as-is and try again next time compaction fires.
+		fmt.Fprintln(os.Stderr, "storage: skipping compaction, current state isn't fully representable in a snapshot yet")
+		return nil
+	}
+
+	// Count what's actually in the WAL right now (not just
+	// appendsSinceCompaction, which Compact called directly wouldn't
+	// have kept in sync) and stamp it into the snapshot. That's what
+	// lets Open skip already-applied records on the next startup if the
+	// truncate below doesn't happen, rather than relying on the
+	// snapshot-write and WAL-truncate being atomic with each other.
+	walPath := filepath.Join(s.dir, walFileName)
+	existing, err := ReadAll(walPath)
+	if err != nil {
+		return fmt.Errorf("storage: read wal before compaction: %v", err)
+	}
+
+	snapshotPath := filepath.Join(s.dir, snapshotFileName)
+	if err := WriteSnapshot(snapshotPath, tables, uint64(len(existing))); err != nil {
+		return err
+	}
+
+	// Everything in existing is now captured by the snapshot we just
+	// durably wrote. Closing and truncating the WAL from here on is
+	// just cleanup to keep it from growing forever: if either step
+	// fails, or the process crashes before they complete, the WAL seq
+	// recorded above means the next Open skips these records instead of
+	// replaying duplicates.
+	if err := s.wal.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Truncate(walPath, 0); err != nil {
+		return fmt.Errorf("storage: truncate wal: %v", err)
+	}
+
+	// The records counted above are gone from disk now, so restamp the
+	// snapshot with 0; otherwise the next Open would skip that many of
+	// the records appended after this point.
+	if err := WriteSnapshot(snapshotPath, tables, 0); err != nil {
+		return err
+	}
+
+	wal, err := OpenWAL(walPath, s.wal.policy, s.wal.syncInterval)
+	if err != nil {
+		return err
+	}
+	s.wal = wal
+
+	return nil
+}
+
+// RunCompactionLoop starts a background goroutine that compacts every
+// interval until Close is called. Call it once after Open if you want
+// time-based (rather than purely count-based) compaction.
+func (s *Store) RunCompactionLoop(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Compact(); err != nil {
+					fmt.Fprintf(os.Stderr, "storage: background compaction failed: %v\n", err)
+				}
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops any background compaction loop and closes the WAL.
+func (s *Store) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.wal.Close()
+}
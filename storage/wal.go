@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+func init() {
+	// MetaData's concrete values come from JSON request bodies decoded
+	// into map[string]any (see httpapi.InsertRequest/BatchInsertRequest),
+	// which only ever produces nil, bool, float64, string, and these two
+	// composite types. gob requires every concrete type that ever
+	// crosses an interface{} boundary to be registered up front, or
+	// Append/ReadAll fail with "gob: type not registered for interface"
+	// the moment MetaData nests a slice or object - exactly the shape
+	// arbitrary JSON metadata takes.
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+}
+
+// OpCode tags a WAL record with the mutation it replays.
+type OpCode byte
+
+const (
+	OpCreateTable OpCode = iota
+	OpInsert
+)
+
+// Record is one WAL entry. Not every field is used by every OpCode: a
+// CreateTable record only needs Table, Capacity and Embedder, an Insert
+// record needs everything else too.
+type Record struct {
+	Op       OpCode
+	Table    string
+	Capacity uint32
+	Embedder EmbedderSpec
+
+	Text     string
+	MetaData map[string]any
+	Time     time.Time
+	Vector   []float32
+}
+
+// EmbedderSpec mirrors embed.Spec, letting storage persist which
+// backend/model/config a table's embedder used without importing embed
+// (storage is a leaf dependency - see SnapshotTable). The zero value
+// means "no spec recorded", e.g. a table created with a caller-supplied
+// Embedder that didn't come from one.
+type EmbedderSpec struct {
+	Backend    string
+	Model      string
+	Dim        int
+	Addr       string
+	BaseURL    string
+	APIKey     string
+	Timeout    time.Duration
+	MaxRetries int
+}
+
+// FsyncPolicy controls how aggressively the WAL calls fsync after a
+// write. Always is safest, None is fastest, Interval is a middle ground.
+type FsyncPolicy int
+
+const (
+	FsyncAlways FsyncPolicy = iota
+	FsyncInterval
+	FsyncNone
+)
+
+// WAL is an append-only log of Records, written with gob so arbitrary
+// MetaData survives round-tripping without a schema.
+type WAL struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	enc    *gob.Encoder
+
+	policy           FsyncPolicy
+	syncInterval     time.Duration
+	writesSinceFsync int
+	lastFsync        time.Time
+}
+
+// OpenWAL opens (creating if needed) the WAL file at path for appending.
+func OpenWAL(path string, policy FsyncPolicy, syncInterval time.Duration) (*WAL, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("storage: open wal %s: %v", path, err)
+	}
+
+	w := bufio.NewWriter(f)
+	return &WAL{
+		file:         f,
+		writer:       w,
+		enc:          gob.NewEncoder(w),
+		policy:       policy,
+		syncInterval: syncInterval,
+		lastFsync:    time.Now(),
+	}, nil
+}
+
+// Append encodes rec and writes it to the log, applying the configured
+// fsync policy.
+func (w *WAL) Append(rec Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.enc.Encode(&rec); err != nil {
+		return fmt.Errorf("storage: encode wal record: %v", err)
+	}
+	if err := w.writer.Flush(); err != nil {
+		return fmt.Errorf("storage: flush wal: %v", err)
+	}
+
+	w.writesSinceFsync++
+
+	switch w.policy {
+	case FsyncAlways:
+		return w.syncLocked()
+	case FsyncInterval:
+		if time.Since(w.lastFsync) >= w.syncInterval {
+			return w.syncLocked()
+		}
+	case FsyncNone:
+		// never fsync; rely on the OS page cache
+	}
+	return nil
+}
+
+func (w *WAL) syncLocked() error {
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("storage: fsync wal: %v", err)
+	}
+	w.writesSinceFsync = 0
+	w.lastFsync = time.Now()
+	return nil
+}
+
+// Close flushes and closes the underlying file.
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.writer.Flush(); err != nil {
+		return err
+	}
+	return w.file.Close()
+}
+
+// ReadAll replays every record currently in the WAL file at path, in
+// order. It's used both at startup (replay onto the latest snapshot)
+// and by compaction (to count the records a new snapshot covers).
+func ReadAll(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage: open wal %s: %v", path, err)
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(bufio.NewReader(f))
+	var records []Record
+	for {
+		var rec Record
+		if err := dec.Decode(&rec); err != nil {
+			break
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
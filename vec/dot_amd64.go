@@ -0,0 +1,61 @@
+//go:build amd64 && !noasm
+
+package vec
+
+import "golang.org/x/sys/cpu"
+
+// dotAVX2 and l2AVX2 are implemented in dot_amd64.s. Both only handle
+// vectors whose length is a multiple of 8 float32s (one YMM register);
+// the wrappers below run the SIMD kernel over the bulk of the vector
+// and fall back to the scalar loop for whatever's left over.
+//
+// There's no AVX-512 variant: the EVEX-encoded masked tail handling it
+// would need is a lot more asm to get right for a width most deployed
+// x86 CPUs either lack or downclock under, for marginal gain over AVX2
+// on vectors this package's callers actually use (embedding dimensions
+// in the hundreds to low thousands). AVX2 plus the scalar fallback
+// covers the hardware this package is likely to run on.
+
+//go:noescape
+func dotAVX2(a, b []float32) float32
+
+//go:noescape
+func l2AVX2(a, b []float32) float32
+
+func init() {
+	if !cpu.X86.HasAVX2 {
+		return
+	}
+	Dot = dotAVX2Wrapper
+	L2 = l2AVX2Wrapper
+	KernelName = "avx2"
+}
+
+func dotAVX2Wrapper(a, b []float32) float32 {
+	n := len(a)
+	if n != len(b) || n < 8 {
+		return dotGeneric(a, b)
+	}
+
+	full := n - n%8
+	sum := dotAVX2(a[:full], b[:full])
+	for i := full; i < n; i++ {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func l2AVX2Wrapper(a, b []float32) float32 {
+	n := len(a)
+	if n != len(b) || n < 8 {
+		return l2Generic(a, b)
+	}
+
+	full := n - n%8
+	sum := l2AVX2(a[:full], b[:full])
+	for i := full; i < n; i++ {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
@@ -0,0 +1,53 @@
+//go:build arm64 && !noasm
+
+package vec
+
+import "golang.org/x/sys/cpu"
+
+// dotNEON and l2NEON are implemented in dot_arm64.s, operating on one
+// 4-lane float32 NEON register per iteration. As with the AVX2 path,
+// the scalar tail (length not a multiple of 4) is handled in Go.
+
+//go:noescape
+func dotNEON(a, b []float32) float32
+
+//go:noescape
+func l2NEON(a, b []float32) float32
+
+func init() {
+	if !cpu.ARM64.HasASIMD {
+		return
+	}
+	Dot = dotNEONWrapper
+	L2 = l2NEONWrapper
+	KernelName = "neon"
+}
+
+func dotNEONWrapper(a, b []float32) float32 {
+	n := len(a)
+	if n != len(b) || n < 4 {
+		return dotGeneric(a, b)
+	}
+
+	full := n - n%4
+	sum := dotNEON(a[:full], b[:full])
+	for i := full; i < n; i++ {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func l2NEONWrapper(a, b []float32) float32 {
+	n := len(a)
+	if n != len(b) || n < 4 {
+		return l2Generic(a, b)
+	}
+
+	full := n - n%4
+	sum := l2NEON(a[:full], b[:full])
+	for i := full; i < n; i++ {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
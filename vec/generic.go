@@ -0,0 +1,24 @@
+package vec
+
+// dotGeneric and l2Generic are the portable reference kernels. They are
+// always correct and are the fallback whenever a vector is shorter than
+// one SIMD register, the CPU or architecture has no asm kernel, or the
+// two input lengths mismatch.
+func dotGeneric(a, b []float32) float32 {
+	n := min(len(a), len(b))
+	var sum float32
+	for i := 0; i < n; i++ {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func l2Generic(a, b []float32) float32 {
+	n := min(len(a), len(b))
+	var sum float32
+	for i := 0; i < n; i++ {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
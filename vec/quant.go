@@ -0,0 +1,37 @@
+package vec
+
+import "math"
+
+// QuantizeInt8 maps v onto the int8 range using a single per-vector
+// scale factor (symmetric, zero-point-free quantization), trading a
+// little precision for a 4x smaller footprint.
+func QuantizeInt8(v []float32) (q []int8, scale float32) {
+	var max float32
+	for _, x := range v {
+		if a := float32(math.Abs(float64(x))); a > max {
+			max = a
+		}
+	}
+
+	scale = 1
+	if max > 0 {
+		scale = max / 127
+	}
+
+	q = make([]int8, len(v))
+	for i, x := range v {
+		q[i] = int8(math.Round(float64(x / scale)))
+	}
+	return q, scale
+}
+
+// DotInt8 computes the dot product of two quantized vectors and
+// rescales the result back into the original float domain.
+func DotInt8(a, b []int8, scaleA, scaleB float32) float32 {
+	n := min(len(a), len(b))
+	var sum int32
+	for i := 0; i < n; i++ {
+		sum += int32(a[i]) * int32(b[i])
+	}
+	return float32(sum) * scaleA * scaleB
+}
@@ -0,0 +1,28 @@
+// Package vec provides distance/similarity kernels over []float32, with
+// a pure-Go reference implementation and CPU-specific SIMD variants
+// selected at init time. Callers just use Dot/L2/Cosine; which kernel
+// actually runs is an implementation detail picked once at startup.
+package vec
+
+import "math"
+
+// Dot and L2 are swapped out for a SIMD implementation in this
+// package's architecture-specific init() functions, if the running CPU
+// supports one. KernelName reports whichever one won.
+var (
+	Dot func(a, b []float32) float32 = dotGeneric
+	L2  func(a, b []float32) float32 = l2Generic
+
+	KernelName = "generic"
+)
+
+// Cosine returns the cosine similarity of a and b, built on top of Dot
+// so it benefits from whichever kernel Dot resolved to.
+func Cosine(a, b []float32) float32 {
+	normA := Dot(a, a)
+	normB := Dot(b, b)
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return Dot(a, b) / (float32(math.Sqrt(float64(normA))) * float32(math.Sqrt(float64(normB))))
+}
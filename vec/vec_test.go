@@ -0,0 +1,97 @@
+package vec
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestKernelsMatchGeneric checks that whichever SIMD kernel this CPU
+// selected (see the architecture-specific init() functions) agrees with
+// the portable reference, across lengths that are exact multiples of
+// the SIMD width, short of it, and with a scalar tail left over.
+func TestKernelsMatchGeneric(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 3, 4, 7, 8, 15, 16, 17, 64, 100} {
+		a := randomVector(r, n)
+		b := randomVector(r, n)
+
+		gotDot, wantDot := Dot(a, b), dotGeneric(a, b)
+		if !almostEqual(gotDot, wantDot) {
+			t.Errorf("Dot(len=%d) = %v, dotGeneric = %v (kernel %s)", n, gotDot, wantDot, KernelName)
+		}
+
+		gotL2, wantL2 := L2(a, b), l2Generic(a, b)
+		if !almostEqual(gotL2, wantL2) {
+			t.Errorf("L2(len=%d) = %v, l2Generic = %v (kernel %s)", n, gotL2, wantL2, KernelName)
+		}
+	}
+}
+
+func TestCosine(t *testing.T) {
+	if got := Cosine([]float32{1, 0}, []float32{1, 0}); !almostEqual(got, 1) {
+		t.Errorf("Cosine(identical unit vectors) = %v, want 1", got)
+	}
+	if got := Cosine([]float32{1, 0}, []float32{0, 1}); !almostEqual(got, 0) {
+		t.Errorf("Cosine(orthogonal vectors) = %v, want 0", got)
+	}
+	if got := Cosine([]float32{0, 0}, []float32{1, 1}); got != 0 {
+		t.Errorf("Cosine(zero vector) = %v, want 0", got)
+	}
+}
+
+// TestQuantizeInt8RoundTrip checks that DotInt8 over a quantized pair
+// approximates the float32 dot product within the precision int8
+// quantization trades away.
+func TestQuantizeInt8RoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	a := randomVector(r, 128)
+	b := randomVector(r, 128)
+
+	qa, scaleA := QuantizeInt8(a)
+	qb, scaleB := QuantizeInt8(b)
+
+	want := dotGeneric(a, b)
+	got := DotInt8(qa, qb, scaleA, scaleB)
+
+	tolerance := float32(0.05) * (abs(want) + 1)
+	if diff := abs(got - want); diff > tolerance {
+		t.Errorf("DotInt8 = %v, float32 dot = %v, diff %v exceeds tolerance %v", got, want, diff, tolerance)
+	}
+}
+
+func TestQuantizeInt8ZeroVector(t *testing.T) {
+	q, scale := QuantizeInt8(make([]float32, 8))
+	if scale != 1 {
+		t.Errorf("scale for an all-zero vector = %v, want 1", scale)
+	}
+	for i, x := range q {
+		if x != 0 {
+			t.Errorf("q[%d] = %d, want 0", i, x)
+		}
+	}
+}
+
+func randomVector(r *rand.Rand, n int) []float32 {
+	v := make([]float32, n)
+	for i := range v {
+		v[i] = r.Float32()*2 - 1
+	}
+	return v
+}
+
+func almostEqual(a, b float32) bool {
+	const epsilon = 1e-3
+	d := a - b
+	if d < 0 {
+		d = -d
+	}
+	return d <= epsilon
+}
+
+func abs(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}